@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"interview_Ping_20241219/internal/config"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/logarchive"
+	"log"
+	"os"
+	"time"
+)
+
+// logarchive runs the game log archival job once, or with -restore loads an
+// archive file back into the database
+func main() {
+	restore := flag.String("restore", "", "archive file (.ndjson.gz) to load back into game_logs")
+	flag.Parse()
+
+	database.InitDB()
+
+	if *restore != "" {
+		restored, err := logarchive.Restore(database.DB, *restore)
+		if err != nil {
+			log.Fatalf("Restore failed after %d rows: %v", restored, err)
+		}
+		log.Printf("Restored %d game logs from %s", restored, *restore)
+		return
+	}
+
+	result, err := logarchive.Run(database.DB, config.GetLogRetentionConfig(), time.Now())
+	if err != nil {
+		log.Fatalf("Archival failed: %v", err)
+	}
+	json.NewEncoder(os.Stdout).Encode(result)
+}
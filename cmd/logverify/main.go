@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"interview_Ping_20241219/internal/config"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"log"
+	"os"
+)
+
+// logverify checks every player's game log hash chain and exits with status 1
+// when it finds tampering. Checkpoint and tombstone signatures are checked
+// against -public-key, or the public half of LOG_SIGNING_KEY.
+func main() {
+	publicKeyFlag := flag.String("public-key", "", "base64 ed25519 public key the checkpoints were signed with")
+	flag.Parse()
+
+	var publicKey ed25519.PublicKey
+	if *publicKeyFlag != "" {
+		decoded, err := base64.StdEncoding.DecodeString(*publicKeyFlag)
+		if err != nil || len(decoded) != ed25519.PublicKeySize {
+			log.Fatalf("invalid -public-key")
+		}
+		publicKey = decoded
+	} else if key := config.GetLogSigningKey(); key != nil {
+		publicKey = key.Public().(ed25519.PublicKey)
+	}
+
+	database.InitDB()
+
+	report, err := gamelog.Verify(database.DB, publicKey)
+	if err != nil {
+		log.Fatalf("Verification failed: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if !report.Valid {
+		os.Exit(1)
+	}
+}
@@ -3,13 +3,47 @@ package main
 import (
     "log"
     "interview_Ping_20241219/internal/api"
+    "interview_Ping_20241219/internal/cache"
+    "interview_Ping_20241219/internal/config"
     "interview_Ping_20241219/internal/database"
+    "interview_Ping_20241219/internal/logarchive"
+    "interview_Ping_20241219/internal/services"
+    "time"
 )
 
 func main() {
+    // Refuse to run the Endless Challenge with a broken fee split
+    if err := services.ValidateChallengeEconomics(); err != nil {
+        log.Fatalf("Invalid challenge configuration: %v", err)
+    }
+
     // Initialize database
     database.InitDB()
 
+    // Initialize Redis cache (optional)
+    cache.InitRedis()
+
+    // Relay new game logs to GET /logs/stream
+    services.StartLogTail()
+
+    // Write async batch logs in the background
+    services.StartLogBuffer()
+
+    // Archive game logs past their retention
+    logarchive.StartScheduler(config.GetLogRetentionConfig())
+
+    // Sign the game log chain heads every hour
+    services.StartLogCheckpoints(time.Hour)
+
+    // Pay out seasons as they end
+    services.StartSeasonScheduler(time.Minute)
+
+    // Move rooms in and out of scheduled maintenance
+    services.StartRoomMaintenanceScheduler(time.Minute)
+
+    // Mark reservations completed or no-show once they end
+    services.StartReservationScheduler(time.Minute)
+
     // Create and setup server
     server := api.NewServer()
 
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"interview_Ping_20241219/internal/engine"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Summary describes the distribution of one simulated metric
+type Summary struct {
+	Metric string  `json:"metric"`
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Min    float64 `json:"min"`
+	P10    float64 `json:"p10"`
+	P25    float64 `json:"p25"`
+	P50    float64 `json:"p50"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+	P99    float64 `json:"p99"`
+	Max    float64 `json:"max"`
+}
+
+type Report struct {
+	Entries            int              `json:"entries"`
+	Players            int              `json:"players"`
+	CooldownSeconds    float64          `json:"cooldown_seconds"`
+	SimulatedDays      float64          `json:"simulated_days"`
+	Economics          engine.Economics `json:"economics"`
+	Wins               int              `json:"wins"`
+	JackpotWins        int              `json:"jackpot_wins"`
+	HouseRevenue       float64          `json:"house_revenue"`
+	FinalPool          engine.PoolState `json:"final_pool"`
+	PoolAtWin          Summary          `json:"pool_at_win"`
+	SecondsBetweenWins Summary          `json:"seconds_between_wins"`
+	JackpotAtWin       Summary          `json:"jackpot_at_win"`
+	HouseRevenuePerDay Summary          `json:"house_revenue_per_day"`
+}
+
+func main() {
+	entries := flag.Int("entries", 1000000, "number of challenge entries to simulate")
+	players := flag.Int("players", 100, "number of players entering as often as the cooldown allows")
+	cooldown := flag.Float64("cooldown", 60, "seconds a player waits between entries")
+	fee := flag.Float64("fee", 20.01, "entry fee")
+	probability := flag.Float64("probability", 0.01, "win probability per entry")
+	rake := flag.Float64("rake", 5, "percentage of each fee kept by the house")
+	jackpot := flag.Float64("jackpot", 2, "percentage of each fee added to the mega-jackpot")
+	megaProbability := flag.Float64("mega-probability", 0.1, "chance that a winner also takes the mega-jackpot")
+	poolSeed := flag.Float64("pool-seed", 100, "amount the house puts back into the pool after a win")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed")
+	format := flag.String("format", "json", "output format: json or csv")
+	output := flag.String("output", "", "output file (default stdout)")
+	flag.Parse()
+
+	if *entries < 1 || *players < 1 || *cooldown <= 0 {
+		log.Fatal("entries, players and cooldown must be positive")
+	}
+	if *format != "json" && *format != "csv" {
+		log.Fatalf("unsupported format %q", *format)
+	}
+
+	economics := engine.Economics{
+		Fee:                    *fee,
+		WinProbability:         *probability,
+		RakePercent:            *rake,
+		JackpotPercent:         *jackpot,
+		MegaJackpotProbability: *megaProbability,
+		MinPoolSeed:            *poolSeed,
+	}
+	if err := economics.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	report := simulate(*entries, *players, *cooldown, economics, rand.New(rand.NewSource(*seed)))
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	var err error
+	if *format == "csv" {
+		err = writeCSV(out, report)
+	} else {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+// simulate runs entries through engine.Resolve. Players enter round-robin as
+// soon as their cooldown allows, so entries arrive every cooldown/players seconds.
+func simulate(entries, players int, cooldown float64, economics engine.Economics, rng engine.RNG) Report {
+	interval := cooldown / float64(players)
+
+	var pool engine.PoolState
+	var houseRevenue float64
+	var revenuePerDay []float64
+	var poolAtWin, secondsBetweenWins, jackpotAtWin []float64
+	lastWin := 0.0
+	jackpotWins := 0
+
+	for i := 0; i < entries; i++ {
+		now := float64(i) * interval
+		outcome := engine.Resolve(pool, economics, rng)
+		pool = outcome.Pool
+		houseRevenue += outcome.Rake - outcome.PoolSeed
+
+		day := int(now / secondsPerDay)
+		for len(revenuePerDay) <= day {
+			revenuePerDay = append(revenuePerDay, 0)
+		}
+		revenuePerDay[day] += outcome.Rake - outcome.PoolSeed
+
+		if outcome.IsWinner {
+			poolAtWin = append(poolAtWin, outcome.Payout)
+			secondsBetweenWins = append(secondsBetweenWins, now-lastWin)
+			lastWin = now
+		}
+		if outcome.IsJackpotWinner {
+			jackpotWins++
+			jackpotAtWin = append(jackpotAtWin, outcome.JackpotPayout)
+		}
+	}
+
+	days := float64(entries) * interval / secondsPerDay
+	return Report{
+		Entries:            entries,
+		Players:            players,
+		CooldownSeconds:    cooldown,
+		SimulatedDays:      days,
+		Economics:          economics,
+		Wins:               len(poolAtWin),
+		JackpotWins:        jackpotWins,
+		HouseRevenue:       engine.RoundToCents(houseRevenue),
+		FinalPool:          pool,
+		PoolAtWin:          summarize("pool_at_win", poolAtWin),
+		SecondsBetweenWins: summarize("seconds_between_wins", secondsBetweenWins),
+		JackpotAtWin:       summarize("jackpot_at_win", jackpotAtWin),
+		HouseRevenuePerDay: summarize("house_revenue_per_day", revenuePerDay),
+	}
+}
+
+func summarize(metric string, values []float64) Summary {
+	summary := Summary{Metric: metric, Count: len(values)}
+	if len(values) == 0 {
+		return summary
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var total float64
+	for _, value := range sorted {
+		total += value
+	}
+
+	percentile := func(p float64) float64 {
+		index := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if index < 0 {
+			index = 0
+		}
+		return sorted[index]
+	}
+
+	summary.Mean = total / float64(len(sorted))
+	summary.Min = sorted[0]
+	summary.P10 = percentile(10)
+	summary.P25 = percentile(25)
+	summary.P50 = percentile(50)
+	summary.P75 = percentile(75)
+	summary.P90 = percentile(90)
+	summary.P99 = percentile(99)
+	summary.Max = sorted[len(sorted)-1]
+	return summary
+}
+
+func writeCSV(out io.Writer, report Report) error {
+	writer := csv.NewWriter(out)
+	writer.Write([]string{"metric", "count", "mean", "min", "p10", "p25", "p50", "p75", "p90", "p99", "max"})
+
+	for _, summary := range []Summary{report.PoolAtWin, report.SecondsBetweenWins, report.JackpotAtWin, report.HouseRevenuePerDay} {
+		row := []string{summary.Metric, strconv.Itoa(summary.Count)}
+		for _, value := range []float64{summary.Mean, summary.Min, summary.P10, summary.P25, summary.P50,
+			summary.P75, summary.P90, summary.P99, summary.Max} {
+			row = append(row, strconv.FormatFloat(value, 'f', 2, 64))
+		}
+		writer.Write(row)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
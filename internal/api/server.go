@@ -24,10 +24,14 @@ func (s *Server) setupRoutes() {
 
 	// Register routes
 	services.RegisterPlayerRoutes(s.router)
+	services.RegisterResponsibleGamingRoutes(s.router)
+	services.RegisterWalletRoutes(s.router)
 	services.RegisterLevelRoutes(s.router)
 	services.RegisterRoomRoutes(s.router)
 	services.RegisterReservationRoutes(s.router)
 	services.RegisterChallengeRoutes(s.router)
+	services.RegisterLeaderboardRoutes(s.router)
+	services.RegisterSeasonRoutes(s.router)
 	services.RegisterLogRoutes(s.router)
 	services.RegisterPaymentRoutes(s.router)
 }
@@ -39,8 +43,8 @@ func (s *Server) Router() *gin.Engine {
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Admin-Token")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrLockNotAcquired = errors.New("lock not acquired")
+
+const (
+	lockRetryInterval = 50 * time.Millisecond
+	// memorySweepMinSize is the fewest keys MemoryLockStore holds before it
+	// sweeps out expired ones
+	memorySweepMinSize = 1024
+)
+
+// LockStore provides cooldowns and locks shared by every app instance
+type LockStore interface {
+	// StartCooldown marks key as cooling down for ttl. When a cooldown is
+	// already running it returns false and the time remaining.
+	StartCooldown(ctx context.Context, key string, ttl time.Duration) (bool, time.Duration, error)
+	// ClearCooldown ends a cooldown early, e.g. when the guarded action failed
+	ClearCooldown(ctx context.Context, key string) error
+	// Lock waits until key is free or ctx is done. The lock expires after ttl
+	// even if the returned unlock function is never called.
+	Lock(ctx context.Context, key string, ttl time.Duration) (func(), error)
+}
+
+// Locks is Redis-backed once InitRedis connects and in-memory otherwise
+var Locks LockStore = NewMemoryLockStore()
+
+// RedisLockStore implements LockStore with SET NX and expiring keys
+type RedisLockStore struct {
+	client *redis.Client
+}
+
+func NewRedisLockStore(client *redis.Client) *RedisLockStore {
+	return &RedisLockStore{client: client}
+}
+
+// unlockScript deletes the lock only if it is still held by the caller's token
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (s *RedisLockStore) StartCooldown(ctx context.Context, key string, ttl time.Duration) (bool, time.Duration, error) {
+	started, err := s.client.SetNX(ctx, key, 1, ttl).Result()
+	if err != nil || started {
+		return started, 0, err
+	}
+
+	remaining, err := s.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	return false, remaining, nil
+}
+
+func (s *RedisLockStore) ClearCooldown(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+func (s *RedisLockStore) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		acquired, err := s.client.SetNX(ctx, key, token, ttl).Result()
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		if acquired {
+			return func() {
+				unlockScript.Run(context.Background(), s.client, []string{key}, token)
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrLockNotAcquired
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// MemoryLockStore implements LockStore for tests and single-node deployments.
+// Expired keys are swept out whenever the map has doubled since the last
+// sweep, so it stays within about twice the keys still live.
+type MemoryLockStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	sweepAt int // size that triggers the next sweep
+}
+
+type memoryEntry struct {
+	token     string
+	expiresAt time.Time
+}
+
+func NewMemoryLockStore() *MemoryLockStore {
+	return &MemoryLockStore{entries: map[string]memoryEntry{}, sweepAt: memorySweepMinSize}
+}
+
+// Len returns the number of keys held, including expired ones not swept yet
+func (s *MemoryLockStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// sweep deletes expired keys. The caller must hold s.mu.
+func (s *MemoryLockStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if !entry.expiresAt.After(now) {
+			delete(s.entries, key)
+		}
+	}
+	s.sweepAt = max(2*len(s.entries), memorySweepMinSize)
+}
+
+// setIfAbsent returns the existing entry when key is still held
+func (s *MemoryLockStore) setIfAbsent(key, token string, ttl time.Duration) (memoryEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := s.entries[key]; ok && existing.expiresAt.After(now) {
+		return existing, false
+	}
+	s.entries[key] = memoryEntry{token: token, expiresAt: now.Add(ttl)}
+	if len(s.entries) >= s.sweepAt {
+		s.sweep(now)
+	}
+	return memoryEntry{}, true
+}
+
+func (s *MemoryLockStore) StartCooldown(ctx context.Context, key string, ttl time.Duration) (bool, time.Duration, error) {
+	existing, started := s.setIfAbsent(key, "", ttl)
+	if started {
+		return true, 0, nil
+	}
+	return false, time.Until(existing.expiresAt), nil
+}
+
+func (s *MemoryLockStore) ClearCooldown(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryLockStore) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, acquired := s.setIfAbsent(key, token, ttl); acquired {
+			return func() {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				if s.entries[key].token == token {
+					delete(s.entries, key)
+				}
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrLockNotAcquired
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+func newLockToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"interview_Ping_20241219/internal/config"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is nil when no Redis server could be reached; callers must then fall
+// back to the database, and Locks only coordinates this instance.
+var Redis *redis.Client
+
+func InitRedis() {
+	redisConfig := config.GetRedisConfig()
+	client := redis.NewClient(&redis.Options{
+		Addr: redisConfig.GetAddr(),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		fmt.Printf("Failed to connect to Redis at %s, continuing without cache: %v\n", redisConfig.GetAddr(), err)
+		client.Close()
+		Redis = nil
+		Locks = NewMemoryLockStore()
+		return
+	}
+
+	Redis = client
+	Locks = NewRedisLockStore(client)
+	fmt.Println("Successfully connected to Redis")
+}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var IsTestEnvironment bool
@@ -41,9 +47,191 @@ func (c DatabaseConfig) GetDSN() string {
 		c.Host, c.User, c.Password, c.DBName, c.Port)
 }
 
+type RedisConfig struct {
+	Host string
+	Port string
+}
+
+func GetRedisConfig() RedisConfig {
+	if IsTestEnvironment {
+		return RedisConfig{
+			Host: "localhost", // Use localhost for tests
+			Port: "6379",
+		}
+	}
+
+	return RedisConfig{
+		Host: getEnvOrDefault("REDIS_HOST", "redis"),
+		Port: getEnvOrDefault("REDIS_PORT", "6379"),
+	}
+}
+
+func (c RedisConfig) GetAddr() string {
+	return fmt.Sprintf("%s:%s", c.Host, c.Port)
+}
+
+// GetAdminToken returns the token expected in the X-Admin-Token header of
+// admin endpoints. Admin endpoints are disabled when it is empty.
+func GetAdminToken() string {
+	if IsTestEnvironment {
+		return "test-admin-token"
+	}
+	return os.Getenv("ADMIN_TOKEN")
+}
+
+// ChallengeConfig describes how each Endless Challenge entry fee is split
+// between the house, the progressive mega-jackpot and the regular pool.
+type ChallengeConfig struct {
+	RakePercent            float64 // share of each entry fee kept as house revenue
+	JackpotPercent         float64 // share of each entry fee added to the mega-jackpot
+	MegaJackpotProbability float64 // chance that a winner also takes the mega-jackpot
+	MinPoolSeed            float64 // amount the house puts back into the pool after a win
+}
+
+func GetChallengeConfig() ChallengeConfig {
+	return ChallengeConfig{
+		RakePercent:            getEnvFloatOrDefault("CHALLENGE_RAKE_PERCENT", 5),
+		JackpotPercent:         getEnvFloatOrDefault("CHALLENGE_JACKPOT_PERCENT", 2),
+		MegaJackpotProbability: getEnvFloatOrDefault("CHALLENGE_MEGA_JACKPOT_PROBABILITY", 0.1),
+		MinPoolSeed:            getEnvFloatOrDefault("CHALLENGE_MIN_POOL_SEED", 100),
+	}
+}
+
+// ReservationConfig is the cancellation policy for room reservations
+type ReservationConfig struct {
+	CancelCutoff  time.Duration // cancelling less than this before the start costs LateCancelFee
+	LateCancelFee float64       // taken from the player's wallet
+	CheckInEarly  time.Duration // how long before the start players can check in
+}
+
+func GetReservationConfig() ReservationConfig {
+	return ReservationConfig{
+		CancelCutoff:  time.Duration(getEnvIntOrDefault("RESERVATION_CANCEL_CUTOFF_HOURS", 24)) * time.Hour,
+		LateCancelFee: getEnvFloatOrDefault("RESERVATION_LATE_CANCEL_FEE", 5),
+		CheckInEarly:  time.Duration(getEnvIntOrDefault("RESERVATION_CHECK_IN_EARLY_MINUTES", 15)) * time.Minute,
+	}
+}
+
+// LogIngestConfig bounds POST /logs/batch and sizes the buffer behind its
+// async mode
+type LogIngestConfig struct {
+	MaxBatchSize  int           // entries accepted per batch request
+	BufferSize    int           // entries the async buffer holds before rejecting more
+	FlushSize     int           // entries written per multi-row insert
+	FlushInterval time.Duration // longest an entry waits in the buffer
+}
+
+func GetLogIngestConfig() LogIngestConfig {
+	return LogIngestConfig{
+		MaxBatchSize:  getEnvPositiveIntOrDefault("LOG_BATCH_MAX_SIZE", 500),
+		BufferSize:    getEnvPositiveIntOrDefault("LOG_BUFFER_SIZE", 10000),
+		FlushSize:     getEnvPositiveIntOrDefault("LOG_BUFFER_FLUSH_SIZE", 500),
+		FlushInterval: time.Duration(getEnvPositiveIntOrDefault("LOG_BUFFER_FLUSH_INTERVAL_MS", 1000)) * time.Millisecond,
+	}
+}
+
+// LogRetentionConfig sets how long game logs are kept in the database before
+// the archival job moves them to compressed files in ArchiveDir
+type LogRetentionConfig struct {
+	DefaultDays     int            // retention of actions without their own setting
+	ActionDays      map[string]int // retention per log action
+	ArchiveDir      string
+	ArchiveInterval time.Duration
+}
+
+// GetLogRetentionConfig reads LOG_RETENTION_ACTION_DAYS as a comma-separated
+// list of action=days pairs, e.g. "login=90,logout=90". Actions are kept as
+// written; callers map legacy Chinese values to their codes.
+func GetLogRetentionConfig() LogRetentionConfig {
+	cfg := LogRetentionConfig{
+		DefaultDays: getEnvPositiveIntOrDefault("LOG_RETENTION_DAYS", 730),
+		ActionDays: map[string]int{
+			"login":      90,
+			"logout":     90,
+			"enter_room": 180,
+			"leave_room": 180,
+		},
+		ArchiveDir:      getEnvOrDefault("LOG_ARCHIVE_DIR", "archive/game_logs"),
+		ArchiveInterval: time.Duration(getEnvPositiveIntOrDefault("LOG_ARCHIVE_INTERVAL_MINUTES", 60)) * time.Minute,
+	}
+
+	for _, pair := range strings.Split(os.Getenv("LOG_RETENTION_ACTION_DAYS"), ",") {
+		action, days, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		if parsed, err := strconv.Atoi(strings.TrimSpace(days)); err == nil && parsed > 0 {
+			cfg.ActionDays[strings.TrimSpace(action)] = parsed
+		}
+	}
+	return cfg
+}
+
+// Days returns the retention of action in days
+func (c LogRetentionConfig) Days(action string) int {
+	if days, ok := c.ActionDays[action]; ok {
+		return days
+	}
+	return c.DefaultDays
+}
+
+// MaxDays is the longest retention of any action. Whole partitions older
+// than this can be dropped.
+func (c LogRetentionConfig) MaxDays() int {
+	max := c.DefaultDays
+	for _, days := range c.ActionDays {
+		if days > max {
+			max = days
+		}
+	}
+	return max
+}
+
+// GetLogSigningKey returns the ed25519 key used to sign game log checkpoints
+// and tombstones, read from LOG_SIGNING_KEY as a base64 32-byte seed. Signing
+// is disabled when it is not set.
+func GetLogSigningKey() ed25519.PrivateKey {
+	if IsTestEnvironment {
+		seed := sha256.Sum256([]byte("test-log-signing-key"))
+		return ed25519.NewKeyFromSeed(seed[:])
+	}
+	seed, err := base64.StdEncoding.DecodeString(os.Getenv("LOG_SIGNING_KEY"))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil
+	}
+	return ed25519.NewKeyFromSeed(seed)
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvPositiveIntOrDefault is getEnvIntOrDefault for settings that must be
+// above zero, such as ticker intervals
+func getEnvPositiveIntOrDefault(key string, defaultValue int) int {
+	if value := getEnvIntOrDefault(key, defaultValue); value > 0 {
+		return value
+	}
+	return defaultValue
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"interview_Ping_20241219/internal/config"
+	"interview_Ping_20241219/internal/gamelog"
 	"interview_Ping_20241219/internal/models"
 	"time"
 
@@ -31,16 +32,45 @@ func InitDB() {
 		panic(fmt.Sprintf("Failed to connect to database after 5 attempts: %v", err))
 	}
 
+	if err := migrateGameLogDetails(DB); err != nil {
+		panic(fmt.Sprintf("Failed to migrate game log details: %v", err))
+	}
+	if err := migrateGameLogPartitions(DB, time.Now()); err != nil {
+		panic(fmt.Sprintf("Failed to partition game logs: %v", err))
+	}
+
+	if err := migrateLogChainPerPlayer(DB); err != nil {
+		panic(fmt.Sprintf("Failed to migrate game log chain: %v", err))
+	}
+
+	// Reservations booked before lifecycle statuses were added
+	backfillReservationStatus := DB.Migrator().HasTable(&models.Reservation{}) &&
+		!DB.Migrator().HasColumn(&models.Reservation{}, "status")
+
 	// Auto Migrate all models
 	err = DB.AutoMigrate(
 		&models.Player{},
 		&models.Level{},
 		&models.Room{},
+		&models.RoomOccupant{},
+		&models.RoomStatusChange{},
+		&models.MaintenanceWindow{},
 		&models.Reservation{},
 		&models.Challenge{},
 		&models.ChallengePool{},
+		&models.HouseRevenue{},
+		&models.Season{},
+		&models.SeasonStanding{},
 		&models.GameLog{},
+		&models.LogAction{},
+		&models.LogChainHead{},
+		&models.GameLogTombstone{},
+		&models.LogCheckpoint{},
 		&models.Payment{},
+		&models.WalletTransaction{},
+		&models.PlayerLimit{},
+		&models.PlayerRestriction{},
+		&models.ResponsibleGamingAudit{},
 	)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to migrate database: %v", err))
@@ -52,5 +82,93 @@ func InitDB() {
 		panic(fmt.Sprintf("Failed to initialize challenge pool: %v", err))
 	}
 
+	// Past reservations from before statuses were tracked count as completed
+	// rather than waiting to be marked as no-shows
+	if backfillReservationStatus {
+		if err := DB.Model(&models.Reservation{}).
+			Where("date < ?", time.Now().UTC().Truncate(24*time.Hour)).
+			Update("status", models.ReservationCompleted).Error; err != nil {
+			panic(fmt.Sprintf("Failed to backfill reservation statuses: %v", err))
+		}
+	}
+
+	// Room status used to be free text; reset anything outside the state machine
+	if err := DB.Model(&models.Room{}).
+		Where("status IS NULL OR status NOT IN ? OR (status = ? AND occupancy = 0)",
+			[]models.RoomStatus{models.RoomStatusAvailable, models.RoomStatusOccupied, models.RoomStatusMaintenance},
+			models.RoomStatusOccupied).
+		Update("status", models.RoomStatusAvailable).Error; err != nil {
+		panic(fmt.Sprintf("Failed to migrate room statuses: %v", err))
+	}
+
+	if err := migrateLogActionCodes(DB); err != nil {
+		panic(fmt.Sprintf("Failed to migrate game log actions: %v", err))
+	}
+	if err := gamelog.LoadActions(DB); err != nil {
+		panic(fmt.Sprintf("Failed to load game log actions: %v", err))
+	}
+	if err := migrateGameLogSearch(DB); err != nil {
+		panic(fmt.Sprintf("Failed to add game log search: %v", err))
+	}
+
+	if err := migrateGameLogNotify(DB); err != nil {
+		panic(fmt.Sprintf("Failed to install game log notifications: %v", err))
+	}
+
 	fmt.Println("Successfully connected to database")
 }
+
+// migrateLogChainPerPlayer replaces the single game log chain with one chain
+// per player. Entries of the single chain cannot be linked into their
+// players' chains without rewriting their hashes, so they become unchained
+// like entries from before chaining, and its head, tombstones and checkpoints
+// are dropped. It is a no-op once chain heads are kept per player.
+func migrateLogChainPerPlayer(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.LogChainHead{}) || db.Migrator().HasColumn(&models.LogChainHead{}, "player_id") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			"UPDATE game_logs SET seq = NULL, prev_hash = '', hash = '' WHERE seq IS NOT NULL",
+			"DROP INDEX IF EXISTS idx_game_logs_seq",
+			"DROP TABLE log_chain_heads",
+			"DROP TABLE IF EXISTS game_log_tombstones",
+			"DROP TABLE IF EXISTS log_checkpoints",
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateGameLogDetails converts the old free-text game_logs.details column to
+// JSONB, keeping existing text as {"message": "..."}. It is a no-op once the
+// column is JSONB or on a fresh database.
+func migrateGameLogDetails(db *gorm.DB) error {
+	return db.Exec(`
+DO $$
+BEGIN
+	IF EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'game_logs' AND column_name = 'details' AND data_type = 'text'
+	) THEN
+		ALTER TABLE game_logs ALTER COLUMN details TYPE jsonb USING
+			CASE WHEN details IS NULL OR details = '' THEN '{}'::jsonb
+			ELSE jsonb_build_object('message', details) END;
+	END IF;
+END $$`).Error
+}
+
+// migrateLogActionCodes renames game logs still holding a legacy Chinese
+// action to its code. It is a no-op once every row is migrated.
+func migrateLogActionCodes(db *gorm.DB) error {
+	for legacy, code := range models.LegacyLogActions {
+		if err := db.Exec("UPDATE game_logs SET action = ? WHERE action = ?", code, legacy).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"interview_Ping_20241219/internal/config"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// GAME_LOG_CHANNEL is the Postgres NOTIFY channel announcing new game logs.
+// Notifications are only delivered once the inserting transaction commits,
+// to every app instance listening.
+const GAME_LOG_CHANNEL = "game_logs"
+
+// gameLogNotifySetting turns the notify trigger off for the transaction that
+// sets it, see SkipGameLogNotify
+const gameLogNotifySetting = "app.skip_game_log_notify"
+
+// SkipGameLogNotify keeps the game logs inserted in tx from being announced
+// on GAME_LOG_CHANNEL, for rows that are not new such as restored archives.
+// It lasts until tx ends.
+func SkipGameLogNotify(tx *gorm.DB) error {
+	return tx.Exec("SELECT set_config(?, 'on', true)", gameLogNotifySetting).Error
+}
+
+// migrateGameLogNotify installs the trigger that notifies GAME_LOG_CHANNEL
+// for every inserted game log. NOTIFY payloads are limited to 8000 bytes, so
+// oversized details are left out and flagged with details_truncated.
+func migrateGameLogNotify(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION notify_game_log() RETURNS trigger AS $$
+DECLARE
+	payload text;
+BEGIN
+	IF current_setting('` + gameLogNotifySetting + `', true) = 'on' THEN
+		RETURN NULL;
+	END IF;
+	payload := json_build_object('id', NEW.id, 'player_id', NEW.player_id, 'action', NEW.action,
+		'details', NEW.details, 'seq', NEW.seq, 'created_at', NEW.created_at)::text;
+	IF octet_length(payload) > 7900 THEN
+		payload := json_build_object('id', NEW.id, 'player_id', NEW.player_id, 'action', NEW.action,
+			'seq', NEW.seq, 'created_at', NEW.created_at, 'details_truncated', true)::text;
+	END IF;
+	PERFORM pg_notify('` + GAME_LOG_CHANNEL + `', payload);
+	RETURN NULL;
+END $$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS game_logs_notify ON game_logs",
+		"CREATE TRIGGER game_logs_notify AFTER INSERT ON game_logs FOR EACH ROW EXECUTE FUNCTION notify_game_log()",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListenGameLogs passes the payload of every game log notification to handle
+// until ctx is done. LISTEN needs its own connection outside the pool; it is
+// re-established after errors.
+func ListenGameLogs(ctx context.Context, handle func(payload string)) {
+	dsn := config.GetDatabaseConfig().GetDSN()
+	for ctx.Err() == nil {
+		if err := listen(ctx, dsn, handle); err != nil && ctx.Err() == nil {
+			log.Printf("Game log listener failed, reconnecting: %v", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func listen(ctx context.Context, dsn string, handle func(payload string)) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+GAME_LOG_CHANNEL); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
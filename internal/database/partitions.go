@@ -0,0 +1,188 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// game_logs is range-partitioned by created_at into one partition per month,
+// named game_logs_pYYYYMM. Rows outside every monthly partition land in
+// game_logs_default.
+const (
+	gameLogPartitionPrefix = "game_logs_p"
+	gameLogPartitionFormat = "200601"
+
+	// GAME_LOG_PARTITIONS_AHEAD is how many future months always have a partition
+	GAME_LOG_PARTITIONS_AHEAD = 2
+)
+
+type GameLogPartition struct {
+	Name string
+	From time.Time // inclusive
+	To   time.Time // exclusive
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func gameLogPartitionFor(t time.Time) GameLogPartition {
+	from := monthStart(t)
+	return GameLogPartition{
+		Name: gameLogPartitionPrefix + from.Format(gameLogPartitionFormat),
+		From: from,
+		To:   from.AddDate(0, 1, 0),
+	}
+}
+
+// EnsureGameLogPartitions creates the monthly partitions covering [from, to]
+// that do not exist yet
+func EnsureGameLogPartitions(db *gorm.DB, from, to time.Time) error {
+	for month := monthStart(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		partition := gameLogPartitionFor(month)
+		if err := createGameLogPartition(db, partition); err != nil {
+			return fmt.Errorf("create partition %s: %w", partition.Name, err)
+		}
+	}
+	return nil
+}
+
+// createGameLogPartition adds the partition unless it exists. Rows of its
+// month already in game_logs_default would keep it from being created, so it
+// is built as a plain table, the rows are moved into it and it is attached,
+// all while inserts into the default partition wait.
+func createGameLogPartition(db *gorm.DB, partition GameLogPartition) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var exists bool
+		if err := tx.Raw("SELECT to_regclass(?) IS NOT NULL", partition.Name).Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+
+		from, to := partition.From.Format(time.RFC3339), partition.To.Format(time.RFC3339)
+		statements := []string{
+			"LOCK TABLE game_logs_default IN EXCLUSIVE MODE",
+			fmt.Sprintf("CREATE TABLE %s (LIKE game_logs INCLUDING DEFAULTS INCLUDING CONSTRAINTS)", partition.Name),
+			fmt.Sprintf(`WITH moved AS (
+				DELETE FROM game_logs_default WHERE created_at >= '%s' AND created_at < '%s' RETURNING *
+			) INSERT INTO %s SELECT * FROM moved`, from, to, partition.Name),
+			fmt.Sprintf("ALTER TABLE game_logs ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')",
+				partition.Name, from, to),
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListGameLogPartitions returns the monthly partitions of game_logs, oldest first
+func ListGameLogPartitions(db *gorm.DB) ([]GameLogPartition, error) {
+	var names []string
+	if err := db.Raw(`SELECT child.relname FROM pg_inherits
+		JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+		JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		WHERE parent.relname = 'game_logs'`).Scan(&names).Error; err != nil {
+		return nil, err
+	}
+
+	var partitions []GameLogPartition
+	for _, name := range names {
+		month, err := time.Parse(gameLogPartitionFormat, strings.TrimPrefix(name, gameLogPartitionPrefix))
+		if !strings.HasPrefix(name, gameLogPartitionPrefix) || err != nil {
+			continue
+		}
+		partitions = append(partitions, gameLogPartitionFor(month))
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].From.Before(partitions[j].From) })
+	return partitions, nil
+}
+
+// migrateGameLogPartitions turns game_logs into a partitioned table. An
+// existing plain table is copied into partitions and dropped in the same
+// transaction. Already partitioned tables only get partitions for the coming
+// months.
+func migrateGameLogPartitions(db *gorm.DB, now time.Time) error {
+	var relkind string
+	if err := db.Raw("SELECT relkind::text FROM pg_class WHERE relname = 'game_logs' AND relnamespace = 'public'::regnamespace").
+		Scan(&relkind).Error; err != nil {
+		return err
+	}
+	if relkind == "p" {
+		return EnsureGameLogPartitions(db, now, now.AddDate(0, GAME_LOG_PARTITIONS_AHEAD, 0))
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		idColumn := "id bigserial"
+		if relkind == "r" {
+			statements := []string{
+				"ALTER TABLE game_logs RENAME TO game_logs_unpartitioned",
+				"ALTER TABLE game_logs_unpartitioned RENAME CONSTRAINT game_logs_pkey TO game_logs_unpartitioned_pkey",
+				"DROP INDEX IF EXISTS idx_game_logs_details",
+			}
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			idColumn = "id bigint NOT NULL DEFAULT nextval('game_logs_id_seq')"
+		}
+
+		statements := []string{
+			`CREATE TABLE game_logs (
+				` + idColumn + `,
+				player_id bigint,
+				action text,
+				details jsonb,
+				created_at timestamptz NOT NULL DEFAULT now(),
+				PRIMARY KEY (id, created_at)
+			) PARTITION BY RANGE (created_at)`,
+			"CREATE TABLE game_logs_default PARTITION OF game_logs DEFAULT",
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+
+		oldest := now
+		if relkind == "r" {
+			var first sql.NullTime
+			if err := tx.Raw("SELECT MIN(created_at) FROM game_logs_unpartitioned").Scan(&first).Error; err != nil {
+				return err
+			}
+			if first.Valid {
+				oldest = first.Time
+			}
+		}
+		if err := EnsureGameLogPartitions(tx, oldest, now.AddDate(0, GAME_LOG_PARTITIONS_AHEAD, 0)); err != nil {
+			return err
+		}
+
+		if relkind != "r" {
+			return nil
+		}
+		statements = []string{
+			`INSERT INTO game_logs (id, player_id, action, details, created_at)
+				SELECT id, player_id, action, details, COALESCE(created_at, now()) FROM game_logs_unpartitioned`,
+			"ALTER SEQUENCE game_logs_id_seq OWNED BY game_logs.id",
+			"DROP TABLE game_logs_unpartitioned",
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
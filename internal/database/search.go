@@ -0,0 +1,45 @@
+package database
+
+import (
+	"fmt"
+	"interview_Ping_20241219/internal/gamelog"
+
+	"gorm.io/gorm"
+)
+
+// GAME_LOG_SEARCH_CONFIG is the text search configuration used for game
+// logs. "simple" only lowercases, so IDs and names are matched as written.
+const GAME_LOG_SEARCH_CONFIG = "simple"
+
+// migrateGameLogSearch adds game_logs.search_vector, a generated tsvector of
+// every string and number in details, with a GIN index. CJK characters are
+// surrounded by separators so each one is its own token.
+func migrateGameLogSearch(db *gorm.DB) error {
+	statements := []string{
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION game_log_search_text(details jsonb, separator text) RETURNS text
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+	SELECT regexp_replace(
+		coalesce((SELECT string_agg(value #>> '{}', ' ')
+			FROM jsonb_path_query(details, 'strict $.**') AS value
+			WHERE jsonb_typeof(value) IN ('string', 'number')), ''),
+		'(%s)', separator || '\1' || separator, 'g')
+$$`, gamelog.CJKCharClass()),
+		fmt.Sprintf(`DO $$
+BEGIN
+	IF NOT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'game_logs' AND column_name = 'search_vector'
+	) THEN
+		ALTER TABLE game_logs ADD COLUMN search_vector tsvector
+			GENERATED ALWAYS AS (to_tsvector('%s', game_log_search_text(details, ' '))) STORED;
+	END IF;
+END $$`, GAME_LOG_SEARCH_CONFIG),
+		"CREATE INDEX IF NOT EXISTS idx_game_logs_search ON game_logs USING gin (search_vector)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// RNG is the source of randomness used to resolve a challenge entry
+type RNG interface {
+	Float64() float64
+}
+
+type globalRNG struct{}
+
+func (globalRNG) Float64() float64 {
+	return rand.Float64()
+}
+
+// DefaultRNG draws from math/rand's shared, goroutine-safe source
+var DefaultRNG RNG = globalRNG{}
+
+// Economics holds the parameters of the Endless Challenge
+type Economics struct {
+	Fee                    float64 `json:"fee"`
+	WinProbability         float64 `json:"win_probability"`
+	RakePercent            float64 `json:"rake_percent"`
+	JackpotPercent         float64 `json:"jackpot_percent"`
+	MegaJackpotProbability float64 `json:"mega_jackpot_probability"`
+	MinPoolSeed            float64 `json:"min_pool_seed"`
+}
+
+var ErrInvalidEconomics = errors.New("invalid challenge economics")
+
+// Validate checks that the fee split and probabilities make sense, so Resolve
+// never takes more than the fee out of an entry or pays out a negative amount
+func (e Economics) Validate() error {
+	switch {
+	case e.Fee < 0:
+		return fmt.Errorf("%w: fee %v is negative", ErrInvalidEconomics, e.Fee)
+	case e.RakePercent < 0 || e.JackpotPercent < 0:
+		return fmt.Errorf("%w: rake %v%% and jackpot %v%% cannot be negative", ErrInvalidEconomics, e.RakePercent, e.JackpotPercent)
+	case e.RakePercent+e.JackpotPercent > 100:
+		return fmt.Errorf("%w: rake %v%% and jackpot %v%% add up to more than 100%%", ErrInvalidEconomics, e.RakePercent, e.JackpotPercent)
+	case e.WinProbability < 0 || e.WinProbability > 1:
+		return fmt.Errorf("%w: win probability %v is not between 0 and 1", ErrInvalidEconomics, e.WinProbability)
+	case e.MegaJackpotProbability < 0 || e.MegaJackpotProbability > 1:
+		return fmt.Errorf("%w: mega-jackpot probability %v is not between 0 and 1", ErrInvalidEconomics, e.MegaJackpotProbability)
+	case e.MinPoolSeed < 0:
+		return fmt.Errorf("%w: minimum pool seed %v is negative", ErrInvalidEconomics, e.MinPoolSeed)
+	}
+	return nil
+}
+
+// PoolState is the regular pool and the progressive mega-jackpot
+type PoolState struct {
+	Amount      float64 `json:"amount"`
+	MegaJackpot float64 `json:"mega_jackpot"`
+}
+
+// Outcome is the result of resolving a single entry
+type Outcome struct {
+	Rake                float64
+	JackpotContribution float64
+	IsWinner            bool
+	Payout              float64 // regular pool paid to the winner
+	IsJackpotWinner     bool
+	JackpotPayout       float64 // mega-jackpot paid to the winner
+	PoolSeed            float64 // amount the house put back into the pool after a win
+	Pool                PoolState
+}
+
+// Resolve applies one entry fee to the pool and decides whether it wins.
+// It has no side effects so JoinChallenge and the simulator share it. The
+// economics must pass Validate.
+func Resolve(pool PoolState, economics Economics, rng RNG) Outcome {
+	outcome := Outcome{
+		Rake:                RoundToCents(economics.Fee * economics.RakePercent / 100),
+		JackpotContribution: RoundToCents(economics.Fee * economics.JackpotPercent / 100),
+	}
+
+	pool.Amount = RoundToCents(pool.Amount + economics.Fee - outcome.Rake - outcome.JackpotContribution)
+	pool.MegaJackpot = RoundToCents(pool.MegaJackpot + outcome.JackpotContribution)
+
+	// The mega-jackpot only pays out on a second draw and otherwise rolls over
+	if rng.Float64() < economics.WinProbability {
+		outcome.IsWinner = true
+		outcome.Payout = pool.Amount
+		if rng.Float64() < economics.MegaJackpotProbability {
+			outcome.IsJackpotWinner = true
+			outcome.JackpotPayout = pool.MegaJackpot
+			pool.MegaJackpot = 0
+		}
+		outcome.PoolSeed = economics.MinPoolSeed
+		pool.Amount = economics.MinPoolSeed
+	}
+
+	outcome.Pool = pool
+	return outcome
+}
+
+func RoundToCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
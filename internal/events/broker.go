@@ -0,0 +1,79 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is a message pushed to live feed subscribers
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+	Time time.Time   `json:"time"`
+}
+
+// Subscription receives events on C until it is closed, either by
+// Unsubscribe or because the subscriber fell too far behind.
+type Subscription struct {
+	C  <-chan Event
+	ch chan Event
+}
+
+// Broker fans events out to subscribers without ever blocking the publisher.
+// A subscriber whose buffer is full is dropped so one slow client cannot hold
+// back the others; it sees C closed and is expected to reconnect.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: map[*Subscription]struct{}{}}
+}
+
+// Challenges carries pool changes, entries and winners from JoinChallenge
+var Challenges = NewBroker()
+
+// Logs carries committed game logs, relayed from Postgres notifications
+var Logs = NewBroker()
+
+func (b *Broker) Subscribe(buffer int) *Subscription {
+	ch := make(chan Event, buffer)
+	sub := &Subscription{C: ch, ch: ch}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+func (b *Broker) Publish(eventType string, data interface{}) {
+	event := Event{Type: eventType, Data: data, Time: time.Now()}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// SubscriberCount is the number of currently connected subscribers
+func (b *Broker) SubscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
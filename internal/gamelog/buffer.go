@@ -0,0 +1,86 @@
+package gamelog
+
+import (
+	"interview_Ping_20241219/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BufferedWriter collects log entries in memory and inserts them with
+// multi-row inserts from a background goroutine. Entries that are accepted
+// but not yet flushed are lost if the process dies, so it is only meant for
+// high-volume client events, never for server actions.
+type BufferedWriter struct {
+	db            *gorm.DB
+	entries       chan models.GameLog
+	flushSize     int
+	flushInterval time.Duration
+}
+
+func NewBufferedWriter(db *gorm.DB, capacity, flushSize int, flushInterval time.Duration) *BufferedWriter {
+	return &BufferedWriter{
+		db:            db,
+		entries:       make(chan models.GameLog, capacity),
+		flushSize:     flushSize,
+		flushInterval: flushInterval,
+	}
+}
+
+// Start runs the flush loop. A batch is written once it reaches flushSize
+// entries or flushInterval has passed, whichever comes first.
+func (w *BufferedWriter) Start() {
+	go func() {
+		ticker := time.NewTicker(w.flushInterval)
+		defer ticker.Stop()
+
+		batch := make([]models.GameLog, 0, w.flushSize)
+		for {
+			select {
+			case entry := <-w.entries:
+				batch = append(batch, entry)
+				if len(batch) >= w.flushSize {
+					batch = w.flush(batch)
+				}
+			case <-ticker.C:
+				batch = w.flush(batch)
+			}
+		}
+	}()
+}
+
+// Enqueue adds an entry without blocking. It returns false when the buffer
+// is full so callers can push back on the client.
+func (w *BufferedWriter) Enqueue(entry models.GameLog) bool {
+	select {
+	case w.entries <- entry:
+		return true
+	default:
+		return false
+	}
+}
+
+// flush writes the batch in one insert. If that fails, the entries are
+// written one at a time so a single bad entry only loses itself.
+func (w *BufferedWriter) flush(batch []models.GameLog) []models.GameLog {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := WriteBatch(w.db, batch); err != nil {
+		log.Printf("Failed to write %d buffered game logs, writing them one by one: %v", len(batch), err)
+		failed := 0
+		for _, entry := range batch {
+			// The rolled back insert may have assigned an ID
+			entry.ID = 0
+			if err := WriteBatch(w.db, []models.GameLog{entry}); err != nil {
+				failed++
+				log.Printf("Dropped buffered game log for player %d (%s): %v", entry.PlayerID, entry.Action, err)
+			}
+		}
+		if failed > 0 {
+			log.Printf("Dropped %d of %d buffered game logs", failed, len(batch))
+		}
+	}
+	return batch[:0]
+}
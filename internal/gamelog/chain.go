@@ -0,0 +1,205 @@
+package gamelog
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Chain appends entries to their players' game log hash chains: each one
+// gets the player's next sequence number, the hash of the player's previous
+// entry and its own hash. It locks the chain heads of the players involved,
+// so tx must be a transaction that also inserts the entries.
+//
+// Each player has their own chain, so writes only wait on other writes for
+// the same player. A head lock is still held until its transaction commits,
+// so callers write their logs as the last step of the transaction, in one
+// batch.
+func Chain(tx *gorm.DB, entries []models.GameLog) error {
+	playerIDs := make([]uint, 0, len(entries))
+	seen := make(map[uint]bool, len(entries))
+	for _, entry := range entries {
+		if !seen[entry.PlayerID] {
+			seen[entry.PlayerID] = true
+			playerIDs = append(playerIDs, entry.PlayerID)
+		}
+	}
+	sort.Slice(playerIDs, func(i, j int) bool { return playerIDs[i] < playerIDs[j] })
+
+	// Create missing heads and lock them in player order, so writers spanning
+	// several players cannot deadlock
+	missing := make([]models.LogChainHead, 0, len(playerIDs))
+	for _, playerID := range playerIDs {
+		missing = append(missing, models.LogChainHead{PlayerID: playerID})
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&missing).Error; err != nil {
+		return fmt.Errorf("create log chain heads: %w", err)
+	}
+	var locked []models.LogChainHead
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("player_id IN ?", playerIDs).
+		Order("player_id").
+		Find(&locked).Error; err != nil {
+		return fmt.Errorf("lock log chain heads: %w", err)
+	}
+	heads := make(map[uint]*models.LogChainHead, len(locked))
+	for i := range locked {
+		heads[locked[i].PlayerID] = &locked[i]
+	}
+
+	now := time.Now()
+	for i := range entries {
+		entry := &entries[i]
+		head := heads[entry.PlayerID]
+		if head == nil {
+			return fmt.Errorf("lock log chain heads: no head for player %d", entry.PlayerID)
+		}
+		seq := head.Seq + 1
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = now
+		}
+		// Postgres keeps microseconds, so hash what will be read back
+		entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+		entry.Seq = &seq
+		entry.PrevHash = head.Hash
+		entry.Hash = Hash(*entry)
+
+		head.Seq = seq
+		head.Hash = entry.Hash
+	}
+
+	for i := range locked {
+		if err := tx.Save(&locked[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteBatch chains and inserts entries with a multi-row insert in one
+// transaction, or in a savepoint of db if it already is one
+func WriteBatch(db *gorm.DB, entries []models.GameLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := Chain(tx, entries); err != nil {
+			return err
+		}
+		return tx.CreateInBatches(entries, 500).Error
+	})
+}
+
+// Hash computes an entry's chain hash from its sequence number, content,
+// creation time and the previous entry's hash
+func Hash(entry models.GameLog) string {
+	details := entry.Details
+	if details == nil {
+		details = models.LogDetails{}
+	}
+	// encoding/json sorts map keys, so equal details always hash the same
+	detailsJSON, _ := json.Marshal(details)
+
+	var seq uint64
+	if entry.Seq != nil {
+		seq = *entry.Seq
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%s|%s|%s|%s",
+		seq, entry.PlayerID, hashAction(entry.Action), detailsJSON,
+		entry.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano), entry.PrevHash)))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashAction is the action value covered by the hash. Built-in actions hash
+// their legacy Chinese value, so rows migrated to action codes still verify.
+func hashAction(action models.LogActionType) string {
+	if legacy, ok := legacyNames[action]; ok {
+		return legacy
+	}
+	return string(action)
+}
+
+// NewTombstone records the chain position of an entry about to be archived,
+// signed so it cannot be forged to hide a deleted entry
+func NewTombstone(entry models.GameLog, key ed25519.PrivateKey, now time.Time) models.GameLogTombstone {
+	tombstone := models.GameLogTombstone{
+		PlayerID:   entry.PlayerID,
+		Seq:        *entry.Seq,
+		PrevHash:   entry.PrevHash,
+		Hash:       entry.Hash,
+		ArchivedAt: now,
+	}
+	if key != nil {
+		tombstone.Signature = sign(key, tombstoneMessage(tombstone))
+	}
+	return tombstone
+}
+
+// CreateCheckpoints signs and stores the head of every player's chain that
+// moved since its last checkpoint. It returns no checkpoints when nothing was
+// logged since.
+func CreateCheckpoints(db *gorm.DB, key ed25519.PrivateKey) ([]models.LogCheckpoint, error) {
+	if key == nil {
+		return nil, fmt.Errorf("no log signing key configured")
+	}
+
+	var heads []models.LogChainHead
+	if err := db.Model(&models.LogChainHead{}).
+		Select("log_chain_heads.*").
+		Joins("LEFT JOIN (SELECT player_id, MAX(seq) AS seq FROM log_checkpoints GROUP BY player_id) AS last " +
+			"ON last.player_id = log_chain_heads.player_id").
+		Where("log_chain_heads.seq > 0 AND (last.seq IS NULL OR last.seq <> log_chain_heads.seq)").
+		Order("log_chain_heads.player_id").
+		Find(&heads).Error; err != nil {
+		return nil, err
+	}
+	if len(heads) == 0 {
+		return nil, nil
+	}
+
+	publicKey := base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	checkpoints := make([]models.LogCheckpoint, 0, len(heads))
+	for _, head := range heads {
+		checkpoint := models.LogCheckpoint{
+			PlayerID:  head.PlayerID,
+			Seq:       head.Seq,
+			Hash:      head.Hash,
+			PublicKey: publicKey,
+			CreatedAt: now,
+		}
+		checkpoint.Signature = sign(key, checkpointMessage(checkpoint))
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	if err := db.CreateInBatches(&checkpoints, 500).Error; err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+func checkpointMessage(checkpoint models.LogCheckpoint) string {
+	return fmt.Sprintf("checkpoint|%d|%d|%s|%s", checkpoint.PlayerID, checkpoint.Seq, checkpoint.Hash,
+		checkpoint.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano))
+}
+
+func tombstoneMessage(tombstone models.GameLogTombstone) string {
+	return fmt.Sprintf("tombstone|%d|%d|%s|%s", tombstone.PlayerID, tombstone.Seq, tombstone.PrevHash, tombstone.Hash)
+}
+
+func sign(key ed25519.PrivateKey, message string) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(message)))
+}
+
+func verifySignature(key ed25519.PublicKey, message, signature string) bool {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	return err == nil && ed25519.Verify(key, []byte(message), sig)
+}
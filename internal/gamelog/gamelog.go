@@ -0,0 +1,51 @@
+package gamelog
+
+import (
+	"interview_Ping_20241219/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// serverActions are emitted by the services themselves and may not be
+// reported by clients through POST /logs. Login and logout have no
+// server-side flow yet and are still reported by clients.
+var serverActions = map[models.LogActionType]bool{
+	models.ActionRegister:      true,
+	models.ActionEnterRoom:     true,
+	models.ActionLeaveRoom:     true,
+	models.ActionJoinChallenge: true,
+	models.ActionChallengeEnd:  true,
+}
+
+func IsServerAction(action models.LogActionType) bool {
+	return serverActions[action]
+}
+
+// NewEntry validates a game log entry without writing it, for callers that
+// collect their entries and write them together with WriteBatch
+func NewEntry(playerID uint, action models.LogActionType, details models.LogDetails) (models.GameLog, error) {
+	if err := ValidateDetails(action, details); err != nil {
+		return models.GameLog{}, err
+	}
+	return models.GameLog{
+		PlayerID: playerID,
+		Action:   action,
+		Details:  details,
+	}, nil
+}
+
+// Write records a game log entry. Pass the transaction of the business change
+// so the entry is committed or rolled back together with it, and make it the
+// last step of that transaction: chaining locks the player's chain head until
+// commit.
+func Write(tx *gorm.DB, playerID uint, action models.LogActionType, details models.LogDetails) (models.GameLog, error) {
+	entry, err := NewEntry(playerID, action, details)
+	if err != nil {
+		return models.GameLog{}, err
+	}
+	entries := []models.GameLog{entry}
+	if err := WriteBatch(tx, entries); err != nil {
+		return models.GameLog{}, err
+	}
+	return entries[0], nil
+}
@@ -0,0 +1,297 @@
+package gamelog
+
+import (
+	"errors"
+	"fmt"
+	"interview_Ping_20241219/internal/models"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ACTION_REFRESH_INTERVAL limits how often a lookup of an unknown action
+// reloads the registry, which picks up actions registered on other instances
+const ACTION_REFRESH_INTERVAL = 5 * time.Second
+
+var (
+	ErrActionExists  = errors.New("log action already exists")
+	ErrInvalidAction = errors.New("invalid log action")
+)
+
+var (
+	actionCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,63}$`)
+	fieldNamePattern  = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+)
+
+var builtinActions = []models.LogAction{
+	{
+		Code:  models.ActionRegister,
+		Names: models.LocalizedNames{"en": "Register", "zh-TW": "註冊"},
+		Fields: models.LogFields{
+			"name":  {Type: FieldString, Required: true},
+			"level": {Type: FieldInteger},
+		},
+	},
+	{
+		Code:  models.ActionLogin,
+		Names: models.LocalizedNames{"en": "Log in", "zh-TW": "登入"},
+		Fields: models.LogFields{
+			"device": {Type: FieldString},
+			"ip":     {Type: FieldString},
+		},
+	},
+	{
+		Code:  models.ActionLogout,
+		Names: models.LocalizedNames{"en": "Log out", "zh-TW": "登出"},
+		Fields: models.LogFields{
+			"device": {Type: FieldString},
+		},
+	},
+	{
+		Code:  models.ActionEnterRoom,
+		Names: models.LocalizedNames{"en": "Enter room", "zh-TW": "進入房間"},
+		Fields: models.LogFields{
+			"room_id": {Type: FieldInteger, Required: true},
+		},
+	},
+	{
+		Code:  models.ActionLeaveRoom,
+		Names: models.LocalizedNames{"en": "Leave room", "zh-TW": "退出房間"},
+		Fields: models.LogFields{
+			"room_id": {Type: FieldInteger, Required: true},
+		},
+	},
+	{
+		Code:  models.ActionJoinChallenge,
+		Names: models.LocalizedNames{"en": "Join challenge", "zh-TW": "參加挑戰"},
+		Fields: models.LogFields{
+			"challenge_id": {Type: FieldInteger, Required: true},
+			"amount":       {Type: FieldNumber, Required: true},
+		},
+	},
+	{
+		Code:  models.ActionChallengeEnd,
+		Names: models.LocalizedNames{"en": "Challenge result", "zh-TW": "挑戰結果"},
+		Fields: models.LogFields{
+			"challenge_id":   {Type: FieldInteger, Required: true},
+			"amount":         {Type: FieldNumber, Required: true},
+			"is_winner":      {Type: FieldBool},
+			"jackpot_amount": {Type: FieldNumber},
+			"voided":         {Type: FieldBool},
+			"reason":         {Type: FieldString},
+			"refund":         {Type: FieldNumber},
+			"reversed":       {Type: FieldNumber},
+		},
+	},
+}
+
+// legacyNames maps built-in action codes back to their legacy Chinese values
+var legacyNames = func() map[models.LogActionType]string {
+	names := make(map[models.LogActionType]string, len(models.LegacyLogActions))
+	for legacy, code := range models.LegacyLogActions {
+		names[code] = legacy
+	}
+	return names
+}()
+
+// builtins returns copies of the built-in actions marked as built in
+func builtins() []models.LogAction {
+	list := make([]models.LogAction, len(builtinActions))
+	for i, action := range builtinActions {
+		action.LegacyName = legacyNames[action.Code]
+		action.Builtin = true
+		list[i] = action
+	}
+	return list
+}
+
+// registry caches the log_actions table. It starts with the built-in
+// actions so validation works before LoadActions is called.
+type registry struct {
+	mu       sync.RWMutex
+	db       *gorm.DB
+	actions  map[models.LogActionType]models.LogAction
+	loadedAt time.Time
+}
+
+var actions = newRegistry()
+
+func newRegistry() *registry {
+	r := &registry{actions: make(map[models.LogActionType]models.LogAction, len(builtinActions))}
+	for _, action := range builtins() {
+		r.actions[action.Code] = action
+	}
+	return r
+}
+
+func (r *registry) get(code models.LogActionType) (models.LogAction, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	action, ok := r.actions[code]
+	return action, ok
+}
+
+func (r *registry) put(action models.LogAction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions[action.Code] = action
+}
+
+func (r *registry) reload() error {
+	var rows []models.LogAction
+	if err := r.db.Find(&rows).Error; err != nil {
+		return err
+	}
+	loaded := make(map[models.LogActionType]models.LogAction, len(rows))
+	for _, action := range rows {
+		loaded[action.Code] = action
+	}
+	r.actions = loaded
+	r.loadedAt = time.Now()
+	return nil
+}
+
+// refresh reloads the registry unless it was loaded within
+// ACTION_REFRESH_INTERVAL and reports whether it did
+func (r *registry) refresh() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.db == nil || time.Since(r.loadedAt) < ACTION_REFRESH_INTERVAL {
+		return false
+	}
+	if err := r.reload(); err != nil {
+		log.Printf("Failed to reload log actions: %v", err)
+		r.loadedAt = time.Now()
+		return false
+	}
+	return true
+}
+
+// LoadActions seeds the built-in actions into log_actions, keeping them in
+// sync with the code, and loads every registered action
+func LoadActions(db *gorm.DB) error {
+	seeded := builtins()
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"names", "fields", "legacy_name", "builtin", "updated_at"}),
+	}).Create(&seeded).Error
+	if err != nil {
+		return err
+	}
+
+	actions.mu.Lock()
+	defer actions.mu.Unlock()
+	actions.db = db
+	return actions.reload()
+}
+
+// LookupAction returns a registered action. An unknown code reloads the
+// registry first in case another instance registered it.
+func LookupAction(code models.LogActionType) (models.LogAction, bool) {
+	if action, ok := actions.get(code); ok {
+		return action, true
+	}
+	if actions.refresh() {
+		return actions.get(code)
+	}
+	return models.LogAction{}, false
+}
+
+// Actions returns every registered action ordered by code
+func Actions() []models.LogAction {
+	actions.mu.RLock()
+	list := make([]models.LogAction, 0, len(actions.actions))
+	for _, action := range actions.actions {
+		list = append(list, action)
+	}
+	actions.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// RegisterAction validates and stores a new, non-built-in action type
+func RegisterAction(db *gorm.DB, action models.LogAction) (models.LogAction, error) {
+	if err := validateAction(action); err != nil {
+		return models.LogAction{}, err
+	}
+	if _, ok := LookupAction(action.Code); ok {
+		return models.LogAction{}, ErrActionExists
+	}
+
+	action.Builtin = false
+	action.LegacyName = ""
+	if action.Fields == nil {
+		action.Fields = models.LogFields{}
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&action)
+	if result.Error != nil {
+		return models.LogAction{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.LogAction{}, ErrActionExists
+	}
+
+	actions.put(action)
+	return action, nil
+}
+
+func validateAction(action models.LogAction) error {
+	if !actionCodePattern.MatchString(string(action.Code)) {
+		return fmt.Errorf("%w: code must be 2-64 lowercase letters, digits or underscores, starting with a letter", ErrInvalidAction)
+	}
+	if action.Names["en"] == "" {
+		return fmt.Errorf("%w: names.en is required", ErrInvalidAction)
+	}
+	for locale, name := range action.Names {
+		if locale == "" || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%w: names must map a locale to a non-empty name", ErrInvalidAction)
+		}
+	}
+	for name, field := range action.Fields {
+		if !fieldNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: invalid field name %q", ErrInvalidAction, name)
+		}
+		if name == "message" {
+			return fmt.Errorf("%w: message is allowed on every action and cannot be redefined", ErrInvalidAction)
+		}
+		switch field.Type {
+		case FieldInteger, FieldNumber, FieldString, FieldBool:
+		default:
+			return fmt.Errorf("%w: field %q has unknown type %q", ErrInvalidAction, name, field.Type)
+		}
+	}
+	return nil
+}
+
+// DisplayName picks the action's name for locale, falling back to another
+// region of the same language, then English, then the code
+func DisplayName(action models.LogAction, locale string) string {
+	if name, ok := action.Names[locale]; ok {
+		return name
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	locales := make([]string, 0, len(action.Names))
+	for candidate := range action.Names {
+		locales = append(locales, candidate)
+	}
+	sort.Strings(locales)
+	for _, candidate := range locales {
+		candidateLanguage, _, _ := strings.Cut(candidate, "-")
+		if language != "" && strings.EqualFold(candidateLanguage, language) {
+			return action.Names[candidate]
+		}
+	}
+
+	if name, ok := action.Names["en"]; ok {
+		return name
+	}
+	return string(action.Code)
+}
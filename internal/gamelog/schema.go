@@ -0,0 +1,120 @@
+package gamelog
+
+import (
+	"fmt"
+	"interview_Ping_20241219/internal/models"
+	"math"
+	"sort"
+	"strconv"
+)
+
+type FieldType = models.LogFieldType
+
+const (
+	FieldInteger = models.LogFieldInteger
+	FieldNumber  = models.LogFieldNumber
+	FieldString  = models.LogFieldString
+	FieldBool    = models.LogFieldBool
+)
+
+type Field = models.LogField
+
+// Schema lists the detail fields an action accepts. Each action's schema is
+// kept with it in the action registry.
+type Schema = models.LogFields
+
+// messageField is allowed on every action for a human-readable note
+var messageField = Field{Type: FieldString}
+
+// IsValidAction reports whether action is a registered log action type
+func IsValidAction(action models.LogActionType) bool {
+	_, ok := LookupAction(action)
+	return ok
+}
+
+func lookupField(action models.LogActionType, name string) (Field, bool) {
+	if name == "message" {
+		return messageField, true
+	}
+	registered, _ := LookupAction(action)
+	field, ok := registered.Fields[name]
+	return field, ok
+}
+
+// ValidateDetails checks details against the action's schema: required fields
+// must be present, unknown fields are rejected and values must match the
+// declared type.
+func ValidateDetails(action models.LogActionType, details models.LogDetails) error {
+	registered, ok := LookupAction(action)
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	schema := registered.Fields
+
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, present := details[name]; schema[name].Required && !present {
+			return fmt.Errorf("details.%s is required", name)
+		}
+	}
+
+	for name, value := range details {
+		field, ok := lookupField(action, name)
+		if !ok {
+			return fmt.Errorf("details.%s is not allowed for this action", name)
+		}
+		if !matchesType(field.Type, value) {
+			return fmt.Errorf("details.%s must be a %s", name, field.Type)
+		}
+	}
+	return nil
+}
+
+func matchesType(fieldType FieldType, value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return fieldType == FieldString
+	case bool:
+		return fieldType == FieldBool
+	case float64:
+		return fieldType == FieldNumber || (fieldType == FieldInteger && v == math.Trunc(v))
+	case int, int32, int64, uint, uint32, uint64:
+		return fieldType == FieldNumber || fieldType == FieldInteger
+	case float32:
+		return fieldType == FieldNumber
+	default:
+		return false
+	}
+}
+
+// ParseDetailFilter converts a query string value for a details field into
+// the typed value used for a JSONB containment match. Without an action the
+// field may belong to any action's schema.
+func ParseDetailFilter(action models.LogActionType, name, raw string) (interface{}, error) {
+	field, ok := lookupField(action, name)
+	if !ok && action == "" {
+		for _, registered := range Actions() {
+			if field, ok = registered.Fields[name]; ok {
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown details field %q", name)
+	}
+
+	switch field.Type {
+	case FieldInteger:
+		return strconv.ParseInt(raw, 10, 64)
+	case FieldNumber:
+		return strconv.ParseFloat(raw, 64)
+	case FieldBool:
+		return strconv.ParseBool(raw)
+	default:
+		return raw, nil
+	}
+}
@@ -0,0 +1,79 @@
+package gamelog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cjkRanges are the scripts written without spaces between words. Postgres
+// has no word splitter for them, so each character is indexed as its own
+// token and searched as a phrase of consecutive characters.
+var cjkRanges = []struct{ lo, hi rune }{
+	{0x3040, 0x30ff}, // Hiragana and Katakana
+	{0x3400, 0x4dbf}, // CJK Extension A
+	{0x4e00, 0x9fff}, // CJK Unified Ideographs
+	{0xac00, 0xd7af}, // Hangul syllables
+	{0xf900, 0xfaff}, // CJK Compatibility Ideographs
+}
+
+func isCJK(r rune) bool {
+	for _, cjk := range cjkRanges {
+		if r >= cjk.lo && r <= cjk.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// CJKCharClass is the regular expression bracket expression matching the
+// same characters as isCJK, for splitting them apart in Postgres
+func CJKCharClass() string {
+	var b strings.Builder
+	b.WriteString("[")
+	for _, cjk := range cjkRanges {
+		fmt.Fprintf(&b, "%c-%c", cjk.lo, cjk.hi)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// SearchQuery prepares user input for websearch_to_tsquery. Latin words keep
+// the websearch syntax (quotes, OR, -word); each run of CJK characters
+// becomes a quoted phrase of single characters, so "房間" matches the two
+// characters next to each other.
+func SearchQuery(q string) string {
+	var b strings.Builder
+	inQuote := false
+	inRun := false
+	var prev rune
+	for _, r := range q {
+		if isCJK(r) {
+			if !inQuote && !inRun {
+				// Keep a leading - attached so the phrase stays negated
+				if prev != '-' {
+					b.WriteRune(' ')
+				}
+				b.WriteRune('"')
+				inRun = true
+			}
+			prev = r
+			b.WriteRune(' ')
+			b.WriteRune(r)
+			b.WriteRune(' ')
+			continue
+		}
+		if inRun {
+			b.WriteString(`" `)
+			inRun = false
+		}
+		if r == '"' {
+			inQuote = !inQuote
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	if inRun {
+		b.WriteString(`"`)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
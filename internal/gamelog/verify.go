@@ -0,0 +1,231 @@
+package gamelog
+
+import (
+	"crypto/ed25519"
+	"database/sql"
+	"fmt"
+	"interview_Ping_20241219/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const maxVerifyIssues = 100
+
+type Issue struct {
+	PlayerID uint   `json:"player_id"`
+	Seq      uint64 `json:"seq"`
+	Type     string `json:"type"`
+	Message  string `json:"message"`
+}
+
+type VerifyReport struct {
+	Valid             bool    `json:"valid"`
+	Chains            int     `json:"chains"`
+	Entries           int     `json:"entries"`
+	Tombstones        int     `json:"tombstones"`
+	Unchained         int64   `json:"unchained"`
+	Checkpoints       int     `json:"checkpoints"`
+	SignaturesChecked bool    `json:"signatures_checked"`
+	Issues            []Issue `json:"issues"`
+	IssuesTruncated   bool    `json:"issues_truncated"`
+}
+
+func (r *VerifyReport) addIssue(playerID uint, seq uint64, issueType, format string, args ...interface{}) {
+	if len(r.Issues) >= maxVerifyIssues {
+		r.IssuesTruncated = true
+		return
+	}
+	r.Issues = append(r.Issues, Issue{PlayerID: playerID, Seq: seq, Type: issueType, Message: fmt.Sprintf(format, args...)})
+}
+
+// Verify walks every player's chain in sequence order, counting archived
+// entries through their tombstones, and reports entries whose content no
+// longer matches their hash, missing or duplicated sequence numbers, broken
+// links, forged tombstones and checkpoints the chains no longer match.
+// Signatures are only checked when key is set. Entries written before
+// chaining existed have no sequence number and are only counted as unchained.
+func Verify(db *gorm.DB, key ed25519.PublicKey) (VerifyReport, error) {
+	report := VerifyReport{Issues: []Issue{}, SignaturesChecked: key != nil}
+
+	if err := db.Model(&models.GameLog{}).Where("seq IS NULL").Count(&report.Unchained).Error; err != nil {
+		return report, err
+	}
+
+	// A head is created with its player's first entry, so chained entries
+	// without one lost it. Checked before reading the heads, which only
+	// ever get added.
+	var headless []uint
+	if err := db.Model(&models.GameLog{}).
+		Distinct("player_id").
+		Where("seq IS NOT NULL AND player_id NOT IN (?)", db.Model(&models.LogChainHead{}).Select("player_id")).
+		Order("player_id").
+		Pluck("player_id", &headless).Error; err != nil {
+		return report, err
+	}
+	for _, playerID := range headless {
+		report.addIssue(playerID, 0, "truncated", "player %d has chained entries but no chain head", playerID)
+	}
+
+	var heads []models.LogChainHead
+	if err := db.Order("player_id").Find(&heads).Error; err != nil {
+		return report, err
+	}
+	report.Chains = len(heads)
+	for _, head := range heads {
+		if err := verifyChain(db, head, key, &report); err != nil {
+			return report, err
+		}
+	}
+
+	report.Valid = len(report.Issues) == 0
+	return report, nil
+}
+
+// verifyChain walks one player's chain up to its head as it was read, so
+// entries logged while verifying are not mistaken for tampering
+func verifyChain(db *gorm.DB, head models.LogChainHead, key ed25519.PublicKey, report *VerifyReport) error {
+	playerID := head.PlayerID
+
+	var checkpoints []models.LogCheckpoint
+	if err := db.Where("player_id = ? AND seq <= ?", playerID, head.Seq).Order("seq, id").Find(&checkpoints).Error; err != nil {
+		return err
+	}
+	report.Checkpoints += len(checkpoints)
+	if key != nil {
+		for _, checkpoint := range checkpoints {
+			if !verifySignature(key, checkpointMessage(checkpoint), checkpoint.Signature) {
+				report.addIssue(playerID, checkpoint.Seq, "checkpoint_signature", "checkpoint %d has an invalid signature", checkpoint.ID)
+			}
+		}
+	}
+
+	entries, err := newCursor[models.GameLog](db, db.Model(&models.GameLog{}).
+		Where("player_id = ? AND seq <= ?", playerID, head.Seq).Order("seq, id"))
+	if err != nil {
+		return err
+	}
+	defer entries.close()
+	tombstones, err := newCursor[models.GameLogTombstone](db, db.Model(&models.GameLogTombstone{}).
+		Where("player_id = ? AND seq <= ?", playerID, head.Seq).Order("seq"))
+	if err != nil {
+		return err
+	}
+	defer tombstones.close()
+
+	expected := uint64(1)
+	prevHash := ""
+	nextCheckpoint := 0
+	for entries.ok || tombstones.ok {
+		var seq uint64
+		var linkHash, hash string
+		switch {
+		case entries.ok && (!tombstones.ok || *entries.item.Seq <= tombstones.item.Seq):
+			entry := entries.item
+			seq, linkHash, hash = *entry.Seq, entry.PrevHash, entry.Hash
+			report.Entries++
+			if Hash(entry) != entry.Hash {
+				report.addIssue(playerID, seq, "modified", "entry %d does not match its hash", entry.ID)
+			}
+			// A restored entry still has its tombstone, which must agree
+			if tombstones.ok && tombstones.item.Seq == seq {
+				if tombstones.item.Hash != entry.Hash {
+					report.addIssue(playerID, seq, "modified", "restored entry %d does not match its archived hash", entry.ID)
+				}
+				if err := tombstones.next(); err != nil {
+					return err
+				}
+			}
+			if err := entries.next(); err != nil {
+				return err
+			}
+		default:
+			tombstone := tombstones.item
+			seq, linkHash, hash = tombstone.Seq, tombstone.PrevHash, tombstone.Hash
+			report.Tombstones++
+			if key != nil && !verifySignature(key, tombstoneMessage(tombstone), tombstone.Signature) {
+				report.addIssue(playerID, seq, "forged_tombstone", "tombstone has an invalid signature")
+			}
+			if err := tombstones.next(); err != nil {
+				return err
+			}
+		}
+
+		if seq < expected {
+			report.addIssue(playerID, seq, "duplicate", "sequence number %d appears more than once", seq)
+			continue
+		}
+		if seq > expected {
+			report.addIssue(playerID, expected, "missing", "entries %d to %d are missing", expected, seq-1)
+		} else if linkHash != prevHash {
+			report.addIssue(playerID, seq, "broken_link", "previous hash does not match entry %d", seq-1)
+		}
+		prevHash = hash
+		expected = seq + 1
+
+		for ; nextCheckpoint < len(checkpoints) && checkpoints[nextCheckpoint].Seq <= seq; nextCheckpoint++ {
+			checkpoint := checkpoints[nextCheckpoint]
+			if checkpoint.Seq == seq && checkpoint.Hash != hash {
+				report.addIssue(playerID, seq, "checkpoint_mismatch", "checkpoint %d does not match the chain", checkpoint.ID)
+			}
+		}
+	}
+	if err := entries.err(); err != nil {
+		return err
+	}
+	if err := tombstones.err(); err != nil {
+		return err
+	}
+
+	last := expected - 1
+	for _, checkpoint := range checkpoints[nextCheckpoint:] {
+		report.addIssue(playerID, checkpoint.Seq, "truncated", "checkpoint %d covers entries up to %d but the chain ends at %d",
+			checkpoint.ID, checkpoint.Seq, last)
+	}
+
+	if head.Seq != last || head.Hash != prevHash {
+		report.addIssue(playerID, head.Seq, "truncated", "chain head is at %d but the last entry found is %d", head.Seq, last)
+	}
+	return nil
+}
+
+// cursor streams query results one row at a time
+type cursor[T any] struct {
+	db   *gorm.DB
+	rows *sql.Rows
+	item T
+	ok   bool
+}
+
+func newCursor[T any](db *gorm.DB, query *gorm.DB) (*cursor[T], error) {
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, err
+	}
+	c := &cursor[T]{db: db, rows: rows}
+	if err := c.next(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *cursor[T]) next() error {
+	c.ok = c.rows.Next()
+	if !c.ok {
+		return nil
+	}
+	var item T
+	if err := c.db.ScanRows(c.rows, &item); err != nil {
+		return err
+	}
+	c.item = item
+	return nil
+}
+
+func (c *cursor[T]) err() error {
+	return c.rows.Err()
+}
+
+func (c *cursor[T]) close() {
+	c.rows.Close()
+}
@@ -0,0 +1,320 @@
+// Package logarchive moves expired game logs out of Postgres into gzipped
+// NDJSON files and loads them back on request.
+package logarchive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/config"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	restoreBatchSize   = 500
+	tombstoneBatchSize = 500
+)
+
+// Record is one archived game log, stored one JSON object per line
+type Record struct {
+	ID        uint                 `json:"id"`
+	PlayerID  uint                 `json:"player_id"`
+	Action    models.LogActionType `json:"action"`
+	Details   models.LogDetails    `json:"details"`
+	Seq       *uint64              `json:"seq,omitempty"`
+	PrevHash  string               `json:"prev_hash,omitempty"`
+	Hash      string               `json:"hash,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+type Result struct {
+	DroppedPartitions []string `json:"dropped_partitions"`
+	ExpiredRows       int      `json:"expired_rows"`
+	Files             []string `json:"files"`
+}
+
+// StartScheduler runs the archival job on the configured interval
+func StartScheduler(cfg config.LogRetentionConfig) {
+	go func() {
+		ticker := time.NewTicker(cfg.ArchiveInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			result, err := Run(database.DB, cfg, time.Now())
+			if err != nil {
+				log.Printf("Game log archival failed: %v", err)
+				continue
+			}
+			if len(result.Files) > 0 {
+				log.Printf("Archived game logs: dropped partitions %v, %d expired rows, files %v",
+					result.DroppedPartitions, result.ExpiredRows, result.Files)
+			}
+		}
+	}()
+}
+
+// Run archives everything past its retention. Monthly partitions that are
+// past the longest retention are exported whole and dropped; remaining
+// expired rows, from actions with a shorter retention or from the default
+// partition, are exported and deleted. Each file is written and synced
+// before the matching rows are removed.
+func Run(db *gorm.DB, cfg config.LogRetentionConfig, now time.Time) (Result, error) {
+	var result Result
+	cfg = normalizeActions(cfg)
+	if err := os.MkdirAll(cfg.ArchiveDir, 0o755); err != nil {
+		return result, err
+	}
+
+	partitions, err := database.ListGameLogPartitions(db)
+	if err != nil {
+		return result, err
+	}
+	key := config.GetLogSigningKey()
+	cutoff := now.AddDate(0, 0, -cfg.MaxDays())
+	for _, partition := range partitions {
+		if partition.To.After(cutoff) {
+			continue
+		}
+		path := filepath.Join(cfg.ArchiveDir, partition.Name+".ndjson.gz")
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if _, err := archiveRows(db.Table(partition.Name).Order("created_at, id"), tx, path, key, now); err != nil {
+				return err
+			}
+			return tx.Exec("DROP TABLE " + partition.Name).Error
+		})
+		if err != nil {
+			return result, fmt.Errorf("archive partition %s: %w", partition.Name, err)
+		}
+		result.DroppedPartitions = append(result.DroppedPartitions, partition.Name)
+		result.Files = append(result.Files, path)
+	}
+
+	where, args := expiredCondition(cfg, now)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		path := filepath.Join(cfg.ArchiveDir, fmt.Sprintf("game_logs_expired_%s.ndjson.gz", now.UTC().Format("20060102T150405Z")))
+		count, err := archiveRows(db.Model(&models.GameLog{}).Where(where, args...).Order("created_at, id"), tx, path, key, now)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return os.Remove(path)
+		}
+		if err := tx.Where(where, args...).Delete(&models.GameLog{}).Error; err != nil {
+			return err
+		}
+		result.ExpiredRows = count
+		result.Files = append(result.Files, path)
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("archive expired rows: %w", err)
+	}
+
+	return result, database.EnsureGameLogPartitions(db, now, now.AddDate(0, database.GAME_LOG_PARTITIONS_AHEAD, 0))
+}
+
+// normalizeActions maps legacy Chinese actions in cfg to their codes, the
+// values stored in game_logs
+func normalizeActions(cfg config.LogRetentionConfig) config.LogRetentionConfig {
+	actionDays := make(map[string]int, len(cfg.ActionDays))
+	for action, days := range cfg.ActionDays {
+		actionDays[string(models.NormalizeLogAction(action))] = days
+	}
+	cfg.ActionDays = actionDays
+	return cfg
+}
+
+// expiredCondition matches rows older than their action's retention
+func expiredCondition(cfg config.LogRetentionConfig, now time.Time) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	actions := make([]string, 0, len(cfg.ActionDays))
+	for action, days := range cfg.ActionDays {
+		conditions = append(conditions, "(action = ? AND created_at < ?)")
+		args = append(args, action, now.AddDate(0, 0, -days))
+		actions = append(actions, action)
+	}
+
+	if len(actions) > 0 {
+		conditions = append(conditions, "(action NOT IN ? AND created_at < ?)")
+		args = append(args, actions, now.AddDate(0, 0, -cfg.DefaultDays))
+	} else {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, now.AddDate(0, 0, -cfg.DefaultDays))
+	}
+	return strings.Join(conditions, " OR "), args
+}
+
+// archiveRows streams the rows of query into a gzipped NDJSON file at path
+// and records a tombstone in tx for every chained entry, so the hash chain
+// stays verifiable once tx removes the rows. The file is written under a
+// temporary name and renamed once synced.
+func archiveRows(query, tx *gorm.DB, path string, key ed25519.PrivateKey, now time.Time) (int, error) {
+	rows, err := query.Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+	defer file.Close()
+
+	tombstones := make([]models.GameLogTombstone, 0, tombstoneBatchSize)
+	flushTombstones := func() error {
+		if len(tombstones) == 0 {
+			return nil
+		}
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tombstones).Error
+		tombstones = tombstones[:0]
+		return err
+	}
+
+	gz := gzip.NewWriter(file)
+	encoder := json.NewEncoder(gz)
+	count := 0
+	for rows.Next() {
+		var entry models.GameLog
+		if err := query.ScanRows(rows, &entry); err != nil {
+			return count, err
+		}
+		if err := encoder.Encode(Record{
+			ID:        entry.ID,
+			PlayerID:  entry.PlayerID,
+			Action:    entry.Action,
+			Details:   entry.Details,
+			Seq:       entry.Seq,
+			PrevHash:  entry.PrevHash,
+			Hash:      entry.Hash,
+			CreatedAt: entry.CreatedAt,
+		}); err != nil {
+			return count, err
+		}
+		count++
+
+		if entry.Seq != nil {
+			tombstones = append(tombstones, gamelog.NewTombstone(entry, key, now))
+			if len(tombstones) == tombstoneBatchSize {
+				if err := flushTombstones(); err != nil {
+					return count, err
+				}
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	if err := flushTombstones(); err != nil {
+		return count, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return count, err
+	}
+	if err := file.Sync(); err != nil {
+		return count, err
+	}
+	if err := file.Close(); err != nil {
+		return count, err
+	}
+	return count, os.Rename(tmp, path)
+}
+
+// Restore loads an archive file back into game_logs, creating partitions as
+// needed. Rows keep their chain fields, and rows that are already present are
+// skipped, so restoring twice is safe. Restored rows are not sent to clients
+// tailing the logs, and are archived again by the next run unless the
+// retention is raised.
+func Restore(db *gorm.DB, path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, err
+	}
+	defer gz.Close()
+
+	restored := 0
+	batch := make([]models.GameLog, 0, restoreBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		from, to := batch[0].CreatedAt, batch[0].CreatedAt
+		for _, entry := range batch {
+			if entry.CreatedAt.Before(from) {
+				from = entry.CreatedAt
+			}
+			if entry.CreatedAt.After(to) {
+				to = entry.CreatedAt
+			}
+		}
+		if err := database.EnsureGameLogPartitions(db, from, to); err != nil {
+			return err
+		}
+		// Restored rows are old, so clients tailing the logs are not sent them
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := database.SkipGameLogNotify(tx); err != nil {
+				return err
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&batch)
+			if result.Error != nil {
+				return result.Error
+			}
+			restored += int(result.RowsAffected)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return restored, fmt.Errorf("line %d: %w", line, err)
+		}
+		batch = append(batch, models.GameLog{
+			ID:        record.ID,
+			PlayerID:  record.PlayerID,
+			Action:    models.NormalizeLogAction(string(record.Action)),
+			Details:   record.Details,
+			Seq:       record.Seq,
+			PrevHash:  record.PrevHash,
+			Hash:      record.Hash,
+			CreatedAt: record.CreatedAt,
+		})
+		if len(batch) == restoreBatchSize {
+			if err := flush(); err != nil {
+				return restored, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return restored, err
+	}
+	return restored, flush()
+}
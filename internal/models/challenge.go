@@ -5,19 +5,46 @@ import (
 )
 
 type Challenge struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	PlayerID  uint      `json:"player_id"`
-	Player    Player    `gorm:"foreignKey:PlayerID" json:"player"`
-	Amount    float64   `json:"amount"`
-	IsWinner  bool      `json:"is_winner"`
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	PlayerID            uint       `json:"player_id"`
+	Player              Player     `gorm:"foreignKey:PlayerID" json:"player"`
+	Amount              float64    `json:"amount"`
+	Rake                float64    `json:"rake"`
+	JackpotContribution float64    `json:"jackpot_contribution"`
+	IsWinner            bool       `json:"is_winner"`
+	IsJackpotWinner     bool       `json:"is_jackpot_winner"`
+	JackpotAmount       float64    `json:"jackpot_amount"`
+	Voided              bool       `gorm:"default:false" json:"voided"`
+	VoidedAt            *time.Time `json:"voided_at,omitempty"`
+	VoidReason          string     `json:"void_reason,omitempty"`
+	PaymentID           *uint      `json:"payment_id,omitempty"`
+	StartTime           time.Time  `json:"start_time"`
+	EndTime             time.Time  `json:"end_time"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 type ChallengePool struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Amount    float64   `json:"amount" gorm:"default:0"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Amount      float64   `json:"amount" gorm:"default:0"`
+	MegaJackpot float64   `json:"mega_jackpot" gorm:"default:0"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type HouseRevenueType string
+
+const (
+	HouseRevenueRake     HouseRevenueType = "rake"
+	HouseRevenuePoolSeed HouseRevenueType = "pool_seed"
+	HouseRevenueVoid     HouseRevenueType = "void"
+)
+
+// HouseRevenue records every amount the house takes from (rake) or puts back
+// into (pool seed, void reversals, stored as negative amounts) the Endless Challenge.
+type HouseRevenue struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	ChallengeID uint             `gorm:"index" json:"challenge_id"`
+	Type        HouseRevenueType `json:"type"`
+	Amount      float64          `json:"amount"`
+	CreatedAt   time.Time        `gorm:"index" json:"created_at"`
 }
@@ -1,26 +1,208 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
+// LogActionType is the stable code of a log action, stored in
+// game_logs.action. Display names live in the LogAction registry.
 type LogActionType string
 
 const (
-	ActionRegister      LogActionType = "註冊"
-	ActionLogin         LogActionType = "登入"
-	ActionLogout        LogActionType = "登出"
-	ActionEnterRoom     LogActionType = "進入房間"
-	ActionLeaveRoom     LogActionType = "退出房間"
-	ActionJoinChallenge LogActionType = "參加挑戰"
-	ActionChallengeEnd  LogActionType = "挑戰結果"
+	ActionRegister      LogActionType = "register"
+	ActionLogin         LogActionType = "login"
+	ActionLogout        LogActionType = "logout"
+	ActionEnterRoom     LogActionType = "enter_room"
+	ActionLeaveRoom     LogActionType = "leave_room"
+	ActionJoinChallenge LogActionType = "join_challenge"
+	ActionChallengeEnd  LogActionType = "challenge_result"
 )
 
+// LegacyLogActions maps the Chinese action values used before action codes
+// to their codes. Old rows are migrated and the API still accepts them.
+var LegacyLogActions = map[string]LogActionType{
+	"註冊":   ActionRegister,
+	"登入":   ActionLogin,
+	"登出":   ActionLogout,
+	"進入房間": ActionEnterRoom,
+	"退出房間": ActionLeaveRoom,
+	"參加挑戰": ActionJoinChallenge,
+	"挑戰結果": ActionChallengeEnd,
+}
+
+// NormalizeLogAction returns the code of a legacy Chinese action value and
+// any other value unchanged
+func NormalizeLogAction(action string) LogActionType {
+	if code, ok := LegacyLogActions[action]; ok {
+		return code
+	}
+	return LogActionType(action)
+}
+
+type LogFieldType string
+
+const (
+	LogFieldInteger LogFieldType = "integer"
+	LogFieldNumber  LogFieldType = "number"
+	LogFieldString  LogFieldType = "string"
+	LogFieldBool    LogFieldType = "boolean"
+)
+
+type LogField struct {
+	Type     LogFieldType `json:"type"`
+	Required bool         `json:"required,omitempty"`
+}
+
+// LogFields lists the detail fields an action accepts, stored as JSONB
+type LogFields map[string]LogField
+
+func (f LogFields) Value() (driver.Value, error) {
+	if f == nil {
+		return "{}", nil
+	}
+	return jsonValue(f)
+}
+
+func (f *LogFields) Scan(value interface{}) error {
+	return jsonScan(value, f)
+}
+
+func (LogFields) GormDataType() string {
+	return "jsonb"
+}
+
+// LocalizedNames maps a locale such as "en" or "zh-TW" to a display name
+type LocalizedNames map[string]string
+
+func (n LocalizedNames) Value() (driver.Value, error) {
+	if n == nil {
+		return "{}", nil
+	}
+	return jsonValue(n)
+}
+
+func (n *LocalizedNames) Scan(value interface{}) error {
+	return jsonScan(value, n)
+}
+
+func (LocalizedNames) GormDataType() string {
+	return "jsonb"
+}
+
+// LogAction registers a log action type: its code, display names and the
+// detail fields it accepts. Built-in actions are seeded at startup; admins
+// can register more.
+type LogAction struct {
+	Code       LogActionType  `gorm:"primaryKey" json:"code"`
+	Names      LocalizedNames `json:"names"`
+	Fields     LogFields      `json:"fields"`
+	LegacyName string         `json:"legacy_name,omitempty"`
+	Builtin    bool           `gorm:"default:false" json:"builtin"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+func jsonValue(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func jsonScan(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", value, dest)
+	}
+}
+
+// LogDetails is the structured payload of a game log, stored as JSONB. The
+// fields allowed for each action are defined in the gamelog package.
+type LogDetails map[string]interface{}
+
+func (d LogDetails) Value() (driver.Value, error) {
+	if d == nil {
+		return "{}", nil
+	}
+	return jsonValue(d)
+}
+
+// Scan reads NULL as empty details. It scans into the plain map so the
+// free-text fallback of UnmarshalJSON does not apply to stored values.
+func (d *LogDetails) Scan(value interface{}) error {
+	*d = LogDetails{}
+	return jsonScan(value, (*map[string]interface{})(d))
+}
+
+// UnmarshalJSON also accepts the old free-text form, which is kept as
+// {"message": "..."} so existing clients keep working
+func (d *LogDetails) UnmarshalJSON(b []byte) error {
+	var message string
+	if err := json.Unmarshal(b, &message); err == nil {
+		*d = LogDetails{}
+		if message != "" {
+			(*d)["message"] = message
+		}
+		return nil
+	}
+	return json.Unmarshal(b, (*map[string]interface{})(d))
+}
+
+func (LogDetails) GormDataType() string {
+	return "jsonb"
+}
+
+// GameLog entries form a hash chain per player: Seq orders the player's
+// entries, and Hash covers the entry's content together with PrevHash, the
+// hash of the player's entry Seq-1.
 type GameLog struct {
 	ID        uint          `gorm:"primaryKey" json:"id"`
-	PlayerID  uint          `json:"player_id"`
+	PlayerID  uint          `gorm:"index:idx_game_logs_player_created,priority:1;index:idx_game_logs_player_seq,priority:1" json:"player_id"`
 	Player    Player        `gorm:"foreignKey:PlayerID" json:"player"`
-	Action    LogActionType `json:"action"`
-	Details   string        `json:"details"`
-	CreatedAt time.Time     `json:"created_at"`
+	Action    LogActionType `gorm:"index:idx_game_logs_action_created,priority:1" json:"action"`
+	Details   LogDetails    `gorm:"type:jsonb;index:idx_game_logs_details,type:gin" json:"details"`
+	Seq       *uint64       `gorm:"index:idx_game_logs_player_seq,priority:2" json:"seq,omitempty"`
+	PrevHash  string        `json:"prev_hash,omitempty"`
+	Hash      string        `json:"hash,omitempty"`
+	CreatedAt time.Time     `gorm:"index:idx_game_logs_player_created,priority:2;index:idx_game_logs_action_created,priority:2" json:"created_at"`
+}
+
+// LogChainHead holds the end of a player's game log chain. Writers lock it
+// to append the player's entries one after another.
+type LogChainHead struct {
+	PlayerID uint   `gorm:"primaryKey;autoIncrement:false" json:"player_id"`
+	Seq      uint64 `json:"seq"`
+	Hash     string `json:"hash"`
+}
+
+// GameLogTombstone keeps the chain position of an archived entry so the
+// chain can still be verified after the row is gone
+type GameLogTombstone struct {
+	PlayerID   uint      `gorm:"primaryKey;autoIncrement:false" json:"player_id"`
+	Seq        uint64    `gorm:"primaryKey;autoIncrement:false" json:"seq"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+	ArchivedAt time.Time `json:"archived_at"`
+	Signature  string    `json:"signature"`
+}
+
+// LogCheckpoint is a signed record of a player's chain head at a point in time
+type LogCheckpoint struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PlayerID  uint      `gorm:"index:idx_log_checkpoints_player_seq,priority:1" json:"player_id"`
+	Seq       uint64    `gorm:"index:idx_log_checkpoints_player_seq,priority:2" json:"seq"`
+	Hash      string    `json:"hash"`
+	PublicKey string    `json:"public_key"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
 }
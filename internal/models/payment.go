@@ -12,6 +12,7 @@ const (
 	PaymentStatusSuccess   PaymentStatus = "success"
 	PaymentStatusFailed    PaymentStatus = "failed"
 	PaymentStatusCancelled PaymentStatus = "cancelled"
+	PaymentStatusRefunded  PaymentStatus = "refunded"
 
 	PaymentMethodCreditCard PaymentMethod = "credit_card"
 	PaymentMethodBank       PaymentMethod = "bank_transfer"
@@ -8,6 +8,7 @@ type Player struct {
     ID        uint      `gorm:"primaryKey" json:"id"`
     Name      string    `gorm:"uniqueIndex;not null" json:"name"`
     Level     uint      `json:"level"`
+    Balance   float64   `gorm:"default:0" json:"balance"`
     CreatedAt time.Time `json:"created_at"`
     UpdatedAt time.Time `json:"updated_at"`
 }
\ No newline at end of file
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+)
+
+type LimitType string
+type LimitPeriod string
+type RestrictionType string
+
+const (
+	LimitTypeDeposit    LimitType = "deposit"
+	LimitTypeLoss       LimitType = "loss"
+	LimitTypeEntryCount LimitType = "entry_count"
+
+	LimitPeriodDay   LimitPeriod = "day"
+	LimitPeriodWeek  LimitPeriod = "week"
+	LimitPeriodMonth LimitPeriod = "month"
+
+	RestrictionCoolOff       RestrictionType = "cool_off"
+	RestrictionSelfExclusion RestrictionType = "self_exclusion"
+)
+
+// PlayerLimit is a player-set limit. Decreases apply immediately; increases
+// and removals wait in the Pending fields until PendingEffectiveAt.
+type PlayerLimit struct {
+	ID                 uint        `gorm:"primaryKey" json:"id"`
+	PlayerID           uint        `gorm:"uniqueIndex:idx_player_limit" json:"player_id"`
+	Type               LimitType   `gorm:"uniqueIndex:idx_player_limit" json:"type"`
+	Period             LimitPeriod `gorm:"uniqueIndex:idx_player_limit" json:"period"`
+	Amount             float64     `json:"amount"`
+	PendingAmount      *float64    `json:"pending_amount,omitempty"`
+	PendingRemoval     bool        `json:"pending_removal,omitempty"`
+	PendingEffectiveAt *time.Time  `json:"pending_effective_at,omitempty"`
+	CreatedAt          time.Time   `json:"created_at"`
+	UpdatedAt          time.Time   `json:"updated_at"`
+}
+
+// PlayerRestriction blocks deposits and challenge entries until EndsAt.
+// A self-exclusion without EndsAt is permanent.
+type PlayerRestriction struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	PlayerID  uint            `gorm:"index" json:"player_id"`
+	Type      RestrictionType `json:"type"`
+	StartsAt  time.Time       `json:"starts_at"`
+	EndsAt    *time.Time      `json:"ends_at"`
+	Reason    string          `json:"reason"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ResponsibleGamingAudit records every change to a player's limits and restrictions
+type ResponsibleGamingAudit struct {
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	PlayerID    uint        `gorm:"index" json:"player_id"`
+	Action      string      `json:"action"`
+	LimitType   LimitType   `json:"limit_type,omitempty"`
+	Period      LimitPeriod `json:"period,omitempty"`
+	OldValue    *float64    `json:"old_value,omitempty"`
+	NewValue    *float64    `json:"new_value,omitempty"`
+	EffectiveAt *time.Time  `json:"effective_at,omitempty"`
+	Details     string      `json:"details,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
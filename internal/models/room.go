@@ -4,24 +4,120 @@ import (
     "time"
 )
 
+type RoomStatus string
+
+const (
+    RoomStatusAvailable   RoomStatus = "available"
+    RoomStatusOccupied    RoomStatus = "occupied"
+    RoomStatusMaintenance RoomStatus = "maintenance"
+)
+
+// roomTransitions lists the statuses a room may move to from each status.
+// Joining and leaving move a room between available and occupied, and a room
+// must be empty to go into maintenance.
+var roomTransitions = map[RoomStatus][]RoomStatus{
+    RoomStatusAvailable:   {RoomStatusOccupied, RoomStatusMaintenance},
+    RoomStatusOccupied:    {RoomStatusAvailable},
+    RoomStatusMaintenance: {RoomStatusAvailable},
+}
+
+func (s RoomStatus) IsValid() bool {
+    _, ok := roomTransitions[s]
+    return ok
+}
+
+// CanTransitionTo reports whether a room in status s may move to next
+func (s RoomStatus) CanTransitionTo(next RoomStatus) bool {
+    for _, allowed := range roomTransitions[s] {
+        if allowed == next {
+            return true
+        }
+    }
+    return false
+}
+
 type Room struct {
-    ID          uint      `gorm:"primaryKey" json:"id"`
-    Name        string    `gorm:"uniqueIndex;not null" json:"name"`
-    Description string    `json:"description"`
-    Status      string    `gorm:"default:'available'" json:"status"` // available, occupied, maintenance
-    CreatedAt   time.Time `json:"created_at"`
-    UpdatedAt   time.Time `json:"updated_at"`
+    ID          uint       `gorm:"primaryKey" json:"id"`
+    Name        string     `gorm:"uniqueIndex;not null" json:"name"`
+    Description string     `json:"description"`
+    Status      RoomStatus `gorm:"not null;default:'available'" json:"status"`
+    Capacity    int        `gorm:"not null;default:4" json:"capacity"`
+    Occupancy   int        `gorm:"not null;default:0" json:"occupancy"` // number of RoomOccupants
+    OpensAt     string     `gorm:"not null;default:'00:00'" json:"opens_at"` // HH:mm UTC, every day
+    ClosesAt    string     `gorm:"not null;default:'24:00'" json:"closes_at"`
+    CreatedAt   time.Time  `json:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// RoomOccupant is a player currently inside a room. A player can be in one
+// room at a time.
+type RoomOccupant struct {
+    ID       uint      `gorm:"primaryKey" json:"id"`
+    RoomID   uint      `gorm:"index;not null" json:"room_id"`
+    PlayerID uint      `gorm:"uniqueIndex;not null" json:"player_id"`
+    Player   Player    `gorm:"foreignKey:PlayerID" json:"player"`
+    JoinedAt time.Time `json:"joined_at"`
 }
 
+type RoomStatusSource string
+
+const (
+    RoomStatusSourceManual      RoomStatusSource = "manual"      // POST /rooms/:id/status
+    RoomStatusSourceOccupancy   RoomStatusSource = "occupancy"   // players joining or leaving
+    RoomStatusSourceMaintenance RoomStatusSource = "maintenance" // a maintenance window starting or ending
+)
+
+// RoomStatusChange records one status change of a room
+type RoomStatusChange struct {
+    ID                  uint             `gorm:"primaryKey" json:"id"`
+    RoomID              uint             `gorm:"index:idx_room_status_changes_room_created;not null" json:"room_id"`
+    FromStatus          RoomStatus       `gorm:"not null" json:"from_status"`
+    ToStatus            RoomStatus       `gorm:"not null" json:"to_status"`
+    Source              RoomStatusSource `gorm:"not null" json:"source"`
+    Reason              string           `json:"reason,omitempty"`
+    MaintenanceWindowID *uint            `json:"maintenance_window_id,omitempty"`
+    CreatedAt           time.Time        `gorm:"index:idx_room_status_changes_room_created" json:"created_at"`
+}
+
+// MaintenanceWindow is a scheduled period in which a room is put into
+// maintenance and cannot be reserved. StartedAt and EndedAt are set when the
+// scheduler moves the room in and out of maintenance.
+type MaintenanceWindow struct {
+    ID          uint       `gorm:"primaryKey" json:"id"`
+    RoomID      uint       `gorm:"index;not null" json:"room_id"`
+    StartsAt    time.Time  `gorm:"index;not null" json:"starts_at"`
+    EndsAt      time.Time  `gorm:"index;not null" json:"ends_at"`
+    Reason      string     `json:"reason,omitempty"`
+    StartedAt   *time.Time `json:"started_at,omitempty"`
+    EndedAt     *time.Time `json:"ended_at,omitempty"`
+    CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+    CreatedAt   time.Time  `json:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type ReservationStatus string
+
+const (
+    ReservationConfirmed ReservationStatus = "confirmed"
+    ReservationCancelled ReservationStatus = "cancelled"
+    ReservationCheckedIn ReservationStatus = "checked_in"
+    ReservationCompleted ReservationStatus = "completed"
+    ReservationNoShow    ReservationStatus = "no_show"
+)
+
 type Reservation struct {
-    ID        uint      `gorm:"primaryKey" json:"id"`
-    RoomID    uint      `json:"room_id"`
-    Room      Room      `gorm:"foreignKey:RoomID" json:"room"`
-    PlayerID  uint      `json:"player_id"`
-    Player    Player    `gorm:"foreignKey:PlayerID" json:"player"`
-    Date      time.Time `json:"date"`
-    StartTime time.Time `json:"start_time"`
-    EndTime   time.Time `json:"end_time"`
-    CreatedAt time.Time `json:"created_at"`
-    UpdatedAt time.Time `json:"updated_at"`
+    ID              uint              `gorm:"primaryKey" json:"id"`
+    RoomID          uint              `json:"room_id"`
+    Room            Room              `gorm:"foreignKey:RoomID" json:"room"`
+    PlayerID        uint              `json:"player_id"`
+    Player          Player            `gorm:"foreignKey:PlayerID" json:"player"`
+    Date            time.Time         `json:"date"`
+    StartTime       time.Time         `json:"start_time"`
+    EndTime         time.Time         `json:"end_time"`
+    Status          ReservationStatus `gorm:"index;not null;default:'confirmed'" json:"status"`
+    CheckedInAt     *time.Time        `json:"checked_in_at,omitempty"`
+    CancelledAt     *time.Time        `json:"cancelled_at,omitempty"`
+    CancellationFee float64           `gorm:"not null;default:0" json:"cancellation_fee"`
+    CreatedAt       time.Time         `json:"created_at"`
+    UpdatedAt       time.Time         `json:"updated_at"`
 }
\ No newline at end of file
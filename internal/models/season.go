@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+type SeasonStatus string
+
+const (
+	SeasonStatusActive    SeasonStatus = "active"
+	SeasonStatusFinalized SeasonStatus = "finalized"
+)
+
+// Season is a time-boxed competition over Endless Challenge entries. Players
+// earn EntryPoints per entry and WinPoints per win between StartTime and EndTime.
+type Season struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Name        string       `gorm:"uniqueIndex;not null" json:"name"`
+	StartTime   time.Time    `json:"start_time"`
+	EndTime     time.Time    `gorm:"index" json:"end_time"`
+	PrizePool   float64      `json:"prize_pool"`
+	TopN        int          `json:"top_n"`
+	EntryPoints int          `json:"entry_points"`
+	WinPoints   int          `json:"win_points"`
+	Status      SeasonStatus `gorm:"index;default:'active'" json:"status"`
+	FinalizedAt *time.Time   `json:"finalized_at,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// SeasonStanding is a player's archived final position in a season
+type SeasonStanding struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	SeasonID   uint      `gorm:"uniqueIndex:idx_season_player" json:"season_id"`
+	PlayerID   uint      `gorm:"uniqueIndex:idx_season_player" json:"player_id"`
+	PlayerName string    `json:"player_name"`
+	Rank       int       `json:"rank"`
+	Points     int64     `json:"points"`
+	Entries    int64     `json:"entries"`
+	Wins       int64     `json:"wins"`
+	Prize      float64   `json:"prize"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+type WalletTransactionType string
+
+const (
+	// Credited with the entry fee when a challenge is voided
+	WalletChallengeRefund WalletTransactionType = "challenge_refund"
+	WalletSeasonPrize     WalletTransactionType = "season_prize"
+	// Credited when a captured payment could not be refunded by its processor
+	WalletPaymentCompensation WalletTransactionType = "payment_compensation"
+	// Debited when a reservation is cancelled after the free cancellation cutoff
+	WalletReservationFee WalletTransactionType = "reservation_cancellation_fee"
+	// Debited when a winning challenge is voided, taking back its payout
+	WalletChallengeWinReversal WalletTransactionType = "challenge_win_reversal"
+)
+
+// WalletTransaction is one entry in the ledger behind Player.Balance
+type WalletTransaction struct {
+	ID        uint                  `gorm:"primaryKey" json:"id"`
+	PlayerID  uint                  `gorm:"index" json:"player_id"`
+	Type      WalletTransactionType `json:"type"`
+	Amount    float64               `json:"amount"`
+	Balance   float64               `json:"balance"` // player balance after this entry
+	Reference string                `json:"reference"`
+	CreatedAt time.Time             `json:"created_at"`
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"crypto/subtle"
+	"interview_Ping_20241219/internal/config"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ADMIN_TOKEN_HEADER = "X-Admin-Token"
+
+// RequireAdmin guards operator endpoints with the configured admin token
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := config.GetAdminToken()
+		if expected == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Admin API is disabled",
+			})
+			return
+		}
+
+		provided := c.GetHeader(ADMIN_TOKEN_HEADER)
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid admin token",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
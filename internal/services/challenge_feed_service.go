@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/events"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	EventChallengePool   = "pool"
+	EventChallengeEntry  = "entry"
+	EventChallengeWinner = "winner"
+
+	FEED_BUFFER_SIZE    = 64
+	FEED_HEARTBEAT      = 15 * time.Second
+	FEED_WRITE_DEADLINE = 10 * time.Second
+)
+
+var challengeFeedUpgrader = websocket.Upgrader{
+	// Same policy as corsMiddleware: any origin may read the public feed
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// publishChallengeEvents is called by JoinChallenge once the entry is committed
+func publishChallengeEvents(player models.Player, challenge models.Challenge, pool models.ChallengePool) {
+	events.Challenges.Publish(EventChallengeEntry, gin.H{
+		"challenge_id": challenge.ID,
+		"player_id":    player.ID,
+		"player_name":  player.Name,
+		"is_winner":    challenge.IsWinner,
+	})
+	if challenge.IsWinner {
+		events.Challenges.Publish(EventChallengeWinner, gin.H{
+			"challenge_id":      challenge.ID,
+			"player_id":         player.ID,
+			"player_name":       player.Name,
+			"amount":            challenge.Amount,
+			"is_jackpot_winner": challenge.IsJackpotWinner,
+			"jackpot_amount":    challenge.JackpotAmount,
+		})
+	}
+	events.Challenges.Publish(EventChallengePool, poolEventData(pool))
+}
+
+func poolEventData(pool models.ChallengePool) gin.H {
+	return gin.H{
+		"pool_amount":  pool.Amount,
+		"mega_jackpot": pool.MegaJackpot,
+	}
+}
+
+// currentPoolEvent is sent first so clients can render before the next entry
+func currentPoolEvent() events.Event {
+	var pool models.ChallengePool
+	if err := database.DB.First(&pool).Error; err != nil {
+		pool = models.ChallengePool{Amount: 0}
+	}
+	return events.Event{Type: EventChallengePool, Data: poolEventData(pool), Time: time.Now()}
+}
+
+// StreamChallenges handles GET /challenges/stream as Server-Sent Events
+func StreamChallenges(c *gin.Context) {
+	sub := events.Challenges.Subscribe(FEED_BUFFER_SIZE)
+	defer events.Challenges.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	snapshot := currentPoolEvent()
+	c.SSEvent(snapshot.Type, snapshot)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(FEED_HEARTBEAT)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped by the broker for falling behind
+				c.SSEvent("error", gin.H{"error": "Client too slow, please reconnect"})
+				c.Writer.Flush()
+				return
+			}
+			c.SSEvent(event.Type, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// ChallengeFeedWebSocket handles GET /challenges/ws
+func ChallengeFeedWebSocket(c *gin.Context) {
+	conn, err := challengeFeedUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already replied with an HTTP error
+		return
+	}
+	defer conn.Close()
+
+	sub := events.Challenges.Subscribe(FEED_BUFFER_SIZE)
+	defer events.Challenges.Unsubscribe(sub)
+
+	// The feed is one-way; reading only handles control frames and notices the client leaving
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(event events.Event) error {
+		conn.SetWriteDeadline(time.Now().Add(FEED_WRITE_DEADLINE))
+		return conn.WriteJSON(event)
+	}
+
+	if err := write(currentPoolEvent()); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(FEED_HEARTBEAT)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "client too slow"),
+					time.Now().Add(FEED_WRITE_DEADLINE))
+				return
+			}
+			if err := write(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(FEED_WRITE_DEADLINE)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -1,13 +1,22 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"interview_Ping_20241219/internal/cache"
+	"interview_Ping_20241219/internal/config"
 	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/engine"
+	"interview_Ping_20241219/internal/gamelog"
 	"interview_Ping_20241219/internal/models"
-	"math/rand"
+	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -15,19 +24,36 @@ const (
 	WIN_PROBABILITY    = 0.01 // 1%
 	CHALLENGE_DURATION = 30   // seconds
 	COOLDOWN_DURATION  = 60   // seconds
+
+	PLAYER_LOCK_TTL  = 10 * time.Second
+	PLAYER_LOCK_WAIT = 2 * time.Second
 )
 
+// lockPlayer serializes a player's entries and deposits across all app
+// instances, so limit checks and the charges they allow cannot interleave
+func lockPlayer(ctx context.Context, playerID uint) (func(), error) {
+	return cache.Locks.Lock(ctx, fmt.Sprintf("lock:player:%d", playerID), PLAYER_LOCK_TTL)
+}
+
 func RegisterChallengeRoutes(router *gin.Engine) {
 	challenges := router.Group("/challenges")
 	{
 		challenges.POST("", JoinChallenge)
 		challenges.GET("/results", GetChallengeResults)
+		challenges.GET("/revenue", RequireAdmin(), GetHouseRevenue)
+		challenges.GET("/stream", StreamChallenges)
+		challenges.GET("/ws", ChallengeFeedWebSocket)
+		challenges.POST("/void", RequireAdmin(), VoidChallenges)
+		challenges.POST("/:id/void", RequireAdmin(), VoidChallenge)
 	}
 }
 
 type JoinChallengeRequest struct {
 	PlayerID uint    `json:"player_id" binding:"required"`
 	Amount   float64 `json:"amount" binding:"required,eq=20.01"`
+	// Optional: charge the entry fee with this method as part of the entry
+	PaymentMethod  models.PaymentMethod `json:"payment_method"`
+	PaymentDetails string               `json:"payment_details"`
 }
 
 func JoinChallenge(c *gin.Context) {
@@ -39,6 +65,12 @@ func JoinChallenge(c *gin.Context) {
 		})
 		return
 	}
+	if req.PaymentMethod != "" && CreatePaymentProcessor(req.PaymentMethod) == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid payment method",
+		})
+		return
+	}
 
 	// Check if player exists
 	var player models.Player
@@ -49,45 +81,148 @@ func JoinChallenge(c *gin.Context) {
 		return
 	}
 
-	// Check cooldown period
-	var lastChallenge models.Challenge
-	database.DB.Where("player_id = ?", req.PlayerID).
-		Order("created_at DESC").
-		First(&lastChallenge)
+	// Serialize entries per player across all app instances
+	ctx, cancel := context.WithTimeout(c.Request.Context(), PLAYER_LOCK_WAIT)
+	defer cancel()
+	unlock, err := lockPlayer(ctx, req.PlayerID)
+	if errors.Is(err, cache.ErrLockNotAcquired) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Another challenge request for this player is in progress",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to lock player",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer unlock()
 
-	if !lastChallenge.CreatedAt.IsZero() &&
-		time.Since(lastChallenge.CreatedAt).Seconds() < COOLDOWN_DURATION {
+	// Enforce responsible gaming restrictions and challenge limits
+	violation, err := checkChallengeLimits(req.PlayerID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to check challenge limits",
+			"details": err.Error(),
+		})
+		return
+	}
+	if violation != nil {
+		c.JSON(http.StatusForbidden, violation.response())
+		return
+	}
+
+	// Check cooldown period. It is started up front so concurrent requests
+	// cannot both pass, and cleared again if the entry is not recorded.
+	cooldownKey := fmt.Sprintf("cooldown:challenge:player:%d", req.PlayerID)
+	started, remaining, err := cache.Locks.StartCooldown(ctx, cooldownKey, COOLDOWN_DURATION*time.Second)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to check cooldown",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !started {
 		c.JSON(http.StatusTooEarly, gin.H{
 			"error":     "Please wait one minute between challenges",
-			"wait_time": COOLDOWN_DURATION - time.Since(lastChallenge.CreatedAt).Seconds(),
+			"wait_time": remaining.Seconds(),
 		})
 		return
 	}
+	committed := false
+	var payment *models.Payment
+	defer func() {
+		if committed {
+			return
+		}
+		cache.Locks.ClearCooldown(context.Background(), cooldownKey)
+		// Compensate a captured payment whose entry was never recorded
+		if payment != nil {
+			if err := refundPayment(*payment, "challenge entry could not be created"); err != nil {
+				log.Printf("Failed to compensate payment %d: %v", payment.ID, err)
+			}
+		}
+	}()
+
+	// Pay and play: charge the entry fee before the entry is resolved
+	if req.PaymentMethod != "" {
+		violation, err := checkDepositLimits(req.PlayerID, CHALLENGE_COST, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to check deposit limits",
+				"details": err.Error(),
+			})
+			return
+		}
+		if violation != nil {
+			c.JSON(http.StatusForbidden, violation.response())
+			return
+		}
+
+		captured, err := capturePayment(req.PlayerID, CHALLENGE_COST, req.PaymentMethod, req.PaymentDetails)
+		if errors.Is(err, ErrPaymentDeclined) {
+			c.JSON(http.StatusPaymentRequired, gin.H{
+				"error":      "Payment failed",
+				"details":    captured.ErrorMessage,
+				"payment_id": captured.ID,
+			})
+			return
+		}
+		if err != nil {
+			// The charge may have gone through before the record failed to save
+			if captured.Status == models.PaymentStatusSuccess {
+				payment = &captured
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to record payment",
+				"details": err.Error(),
+			})
+			return
+		}
+		payment = &captured
+	}
 
 	// Start transaction
 	tx := database.DB.Begin()
 
-	// Update pool
+	// Lock the pool so concurrent entries resolve one at a time
 	var pool models.ChallengePool
-	if err := tx.First(&pool).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pool).Error; err != nil {
 		pool = models.ChallengePool{Amount: 0}
 	}
-	pool.Amount += CHALLENGE_COST
+
+	// Determine if player wins
+	outcome := engine.Resolve(engine.PoolState{Amount: pool.Amount, MegaJackpot: pool.MegaJackpot},
+		challengeEconomics(), engine.DefaultRNG)
+
+	pool.Amount = outcome.Pool.Amount
+	pool.MegaJackpot = outcome.Pool.MegaJackpot
 	if err := tx.Save(&pool).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pool"})
 		return
 	}
 
-	// Determine if player wins
-	isWinner := rand.Float64() < WIN_PROBABILITY
-
 	challenge := models.Challenge{
-		PlayerID:  req.PlayerID,
-		Amount:    CHALLENGE_COST,
-		IsWinner:  isWinner,
-		StartTime: time.Now(),
-		EndTime:   time.Now().Add(time.Second * CHALLENGE_DURATION),
+		PlayerID:            req.PlayerID,
+		Amount:              CHALLENGE_COST,
+		Rake:                outcome.Rake,
+		JackpotContribution: outcome.JackpotContribution,
+		IsWinner:            outcome.IsWinner,
+		IsJackpotWinner:     outcome.IsJackpotWinner,
+		JackpotAmount:       outcome.JackpotPayout,
+		StartTime:           time.Now(),
+		EndTime:             time.Now().Add(time.Second * CHALLENGE_DURATION),
+	}
+	if payment != nil {
+		challenge.PaymentID = &payment.ID
+	}
+	// A winner's amount is the pool they took
+	if outcome.IsWinner {
+		challenge.Amount = outcome.Payout
 	}
 
 	if err := tx.Create(&challenge).Error; err != nil {
@@ -96,29 +231,75 @@ func JoinChallenge(c *gin.Context) {
 		return
 	}
 
-	// If player wins, empty the pool
-	if isWinner {
-		challenge.Amount = pool.Amount
-		pool.Amount = 0
-		if err := tx.Save(&pool).Error; err != nil {
+	// Record the rake and, after a win, the house-funded pool seed
+	var revenues []models.HouseRevenue
+	if outcome.Rake > 0 {
+		revenues = append(revenues, models.HouseRevenue{
+			ChallengeID: challenge.ID,
+			Type:        models.HouseRevenueRake,
+			Amount:      outcome.Rake,
+		})
+	}
+	if outcome.PoolSeed > 0 {
+		revenues = append(revenues, models.HouseRevenue{
+			ChallengeID: challenge.ID,
+			Type:        models.HouseRevenuePoolSeed,
+			Amount:      -outcome.PoolSeed,
+		})
+	}
+	if len(revenues) > 0 {
+		if err := tx.Create(&revenues).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pool"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record house revenue"})
 			return
 		}
-		if err := tx.Save(&challenge).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update challenge"})
-			return
+	}
+
+	// Log the entry and its result with the challenge itself, last so the
+	// log chain is locked only until commit
+	result := models.LogDetails{
+		"challenge_id": challenge.ID,
+		"amount":       0.0,
+		"is_winner":    challenge.IsWinner,
+	}
+	if challenge.IsWinner {
+		result["amount"] = challenge.Amount
+		if challenge.IsJackpotWinner {
+			result["jackpot_amount"] = challenge.JackpotAmount
+		}
+	}
+	joined, err := gamelog.NewEntry(req.PlayerID, models.ActionJoinChallenge,
+		models.LogDetails{"challenge_id": challenge.ID, "amount": CHALLENGE_COST})
+	if err == nil {
+		var ended models.GameLog
+		if ended, err = gamelog.NewEntry(req.PlayerID, models.ActionChallengeEnd, result); err == nil {
+			err = gamelog.WriteBatch(tx, []models.GameLog{joined, ended})
 		}
 	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write game log"})
+		return
+	}
 
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit challenge"})
+		return
+	}
+	committed = true
+
+	publishChallengeEvents(player, challenge, pool)
+	updateLeaderboardCache(player, challenge)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"challenge_id": challenge.ID,
-		"is_winner":    challenge.IsWinner,
-		"amount":       challenge.Amount,
-		"pool_amount":  pool.Amount,
+		"challenge_id":      challenge.ID,
+		"is_winner":         challenge.IsWinner,
+		"amount":            challenge.Amount,
+		"is_jackpot_winner": challenge.IsJackpotWinner,
+		"jackpot_amount":    challenge.JackpotAmount,
+		"pool_amount":       pool.Amount,
+		"mega_jackpot":      pool.MegaJackpot,
+		"payment_id":        challenge.PaymentID,
 	})
 }
 
@@ -138,7 +319,238 @@ func GetChallengeResults(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"challenges":  challenges,
-		"pool_amount": pool.Amount,
+		"challenges":   challenges,
+		"pool_amount":  pool.Amount,
+		"mega_jackpot": pool.MegaJackpot,
+	})
+}
+
+// DailyHouseRevenue is one row of the house revenue report
+type DailyHouseRevenue struct {
+	Date     string  `json:"date"`
+	Rake     float64 `json:"rake"`
+	PoolSeed float64 `json:"pool_seed"`
+	Net      float64 `json:"net"`
+}
+
+// GetHouseRevenue handles GET /challenges/revenue with optional start_date and end_date (YYYY-MM-DD)
+func GetHouseRevenue(c *gin.Context) {
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+
+	query := database.DB.Model(&models.HouseRevenue{}).
+		Select("TO_CHAR(DATE(created_at), 'YYYY-MM-DD') AS date, "+
+			"SUM(CASE WHEN type = ? THEN amount ELSE 0 END) AS rake, "+
+			"SUM(CASE WHEN type = ? THEN amount ELSE 0 END) AS pool_seed, "+
+			"SUM(amount) AS net",
+			models.HouseRevenueRake, models.HouseRevenuePoolSeed)
+
+	if startDate != "" {
+		if _, err := time.Parse("2006-01-02", startDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("DATE(created_at) >= ?", startDate)
+	}
+	if endDate != "" {
+		if _, err := time.Parse("2006-01-02", endDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("DATE(created_at) <= ?", endDate)
+	}
+
+	var days []DailyHouseRevenue
+	if err := query.Group("DATE(created_at)").Order("date DESC").Scan(&days).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch house revenue",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var total float64
+	for _, day := range days {
+		total += day.Net
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":  days,
+		"total": engine.RoundToCents(total),
+	})
+}
+
+// GetPlayerChallenges handles GET /players/:id/challenges with pagination and optional time filters
+func GetPlayerChallenges(c *gin.Context) {
+	id := c.Param("id")
+	startTime := c.Query("start_time")
+	endTime := c.Query("end_time")
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid page parameter",
+		})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid page_size parameter. Use 1-100",
+		})
+		return
+	}
+
+	var player models.Player
+	if err := database.DB.First(&player, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Player not found",
+		})
+		return
+	}
+
+	query := database.DB.Model(&models.Challenge{}).Where("player_id = ?", player.ID)
+	if startTime != "" {
+		start, err := time.Parse(time.RFC3339, startTime)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format. Use RFC 3339"})
+			return
+		}
+		query = query.Where("created_at >= ?", start)
+	}
+	if endTime != "" {
+		end, err := time.Parse(time.RFC3339, endTime)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format. Use RFC 3339"})
+			return
+		}
+		query = query.Where("created_at <= ?", end)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to count challenges",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var challenges []models.Challenge
+	if err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&challenges).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch challenges",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"challenges": challenges,
+		"page":       page,
+		"page_size":  pageSize,
+		"total":      total,
 	})
 }
+
+type PlayerChallengeStats struct {
+	PlayerID            uint       `json:"player_id"`
+	TotalEntries        int64      `json:"total_entries"`
+	TotalSpent          float64    `json:"total_spent"`
+	TotalWon            float64    `json:"total_won"`
+	Net                 float64    `json:"net"`
+	WinCount            int64      `json:"win_count"`
+	LongestLosingStreak int        `json:"longest_losing_streak"`
+	LastWinDate         *time.Time `json:"last_win_date"`
+}
+
+// GetPlayerChallengeStats handles GET /players/:id/challenge-stats
+func GetPlayerChallengeStats(c *gin.Context) {
+	id := c.Param("id")
+
+	var player models.Player
+	if err := database.DB.First(&player, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Player not found",
+		})
+		return
+	}
+
+	stats, err := playerChallengeStats(player.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to compute challenge stats",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func playerChallengeStats(playerID uint) (PlayerChallengeStats, error) {
+	stats := PlayerChallengeStats{PlayerID: playerID}
+
+	var totals struct {
+		WinCount    int64
+		TotalWon    float64
+		LastWinDate *time.Time
+	}
+	if err := database.DB.Model(&models.Challenge{}).
+		Select("COUNT(*) AS win_count, COALESCE(SUM(amount + jackpot_amount), 0) AS total_won, MAX(created_at) AS last_win_date").
+		Where("player_id = ? AND is_winner = ? AND voided = ?", playerID, true, false).
+		Scan(&totals).Error; err != nil {
+		return stats, err
+	}
+
+	// Walk the outcomes in order to find the longest run without a win
+	var outcomes []bool
+	if err := database.DB.Model(&models.Challenge{}).
+		Where("player_id = ? AND voided = ?", playerID, false).
+		Order("created_at, id").
+		Pluck("is_winner", &outcomes).Error; err != nil {
+		return stats, err
+	}
+
+	streak := 0
+	for _, isWinner := range outcomes {
+		if isWinner {
+			streak = 0
+			continue
+		}
+		streak++
+		if streak > stats.LongestLosingStreak {
+			stats.LongestLosingStreak = streak
+		}
+	}
+
+	stats.TotalEntries = int64(len(outcomes))
+	stats.TotalSpent = engine.RoundToCents(float64(stats.TotalEntries) * CHALLENGE_COST)
+	stats.TotalWon = engine.RoundToCents(totals.TotalWon)
+	stats.Net = engine.RoundToCents(stats.TotalWon - stats.TotalSpent)
+	stats.WinCount = totals.WinCount
+	stats.LastWinDate = totals.LastWinDate
+	return stats, nil
+}
+
+// ValidateChallengeEconomics checks the configured fee split, so the server
+// can refuse to start with one Resolve cannot apply
+func ValidateChallengeEconomics() error {
+	return challengeEconomics().Validate()
+}
+
+// challengeEconomics combines the fixed challenge rules with the configured fee split
+func challengeEconomics() engine.Economics {
+	economics := config.GetChallengeConfig()
+	return engine.Economics{
+		Fee:                    CHALLENGE_COST,
+		WinProbability:         WIN_PROBABILITY,
+		RakePercent:            economics.RakePercent,
+		JackpotPercent:         economics.JackpotPercent,
+		MegaJackpotProbability: economics.MegaJackpotProbability,
+		MinPoolSeed:            economics.MinPoolSeed,
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/engine"
+	"interview_Ping_20241219/internal/events"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VoidChallengeRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type VoidChallengesRequest struct {
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+	Reason    string    `json:"reason" binding:"required"`
+}
+
+// VoidChallenge handles POST /challenges/:id/void
+func VoidChallenge(c *gin.Context) {
+	var req VoidChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tx := database.DB.Begin()
+
+	var challenge models.Challenge
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&challenge, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Challenge not found",
+		})
+		return
+	}
+	if challenge.Voided {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Challenge is already voided",
+		})
+		return
+	}
+
+	var pool models.ChallengePool
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pool).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pool"})
+		return
+	}
+
+	entry, refunded, err := voidChallenge(tx, &challenge, &pool, req.Reason, time.Now())
+	if err == nil {
+		err = gamelog.WriteBatch(tx, []models.GameLog{entry})
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to void challenge",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to void challenge"})
+		return
+	}
+
+	events.Challenges.Publish(EventChallengePool, poolEventData(pool))
+
+	c.JSON(http.StatusOK, gin.H{
+		"challenge":    challenge,
+		"refunded":     refunded,
+		"pool_amount":  pool.Amount,
+		"mega_jackpot": pool.MegaJackpot,
+	})
+}
+
+// VoidChallenges handles POST /challenges/void, voiding every challenge
+// created in [start_time, end_time] in a single transaction
+func VoidChallenges(c *gin.Context) {
+	var req VoidChallengesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !req.EndTime.After(req.StartTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "end_time must be after start_time",
+		})
+		return
+	}
+
+	tx := database.DB.Begin()
+
+	var pool models.ChallengePool
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pool).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pool"})
+		return
+	}
+
+	var challenges []models.Challenge
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("created_at BETWEEN ? AND ? AND voided = ?", req.StartTime, req.EndTime, false).
+		Order("id").
+		Find(&challenges).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch challenges",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	voidedIDs := make([]uint, 0, len(challenges))
+	refunded := 0.0
+	entries := make([]models.GameLog, 0, len(challenges))
+	for i := range challenges {
+		entry, refund, err := voidChallenge(tx, &challenges[i], &pool, req.Reason, now)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":        "Failed to void challenge",
+				"challenge_id": challenges[i].ID,
+				"details":      err.Error(),
+			})
+			return
+		}
+		voidedIDs = append(voidedIDs, challenges[i].ID)
+		entries = append(entries, entry)
+		refunded += refund
+	}
+
+	// Chain all the void logs at once, after everything else
+	if err := gamelog.WriteBatch(tx, entries); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to write game logs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to void challenges"})
+		return
+	}
+
+	if len(voidedIDs) > 0 {
+		events.Challenges.Publish(EventChallengePool, poolEventData(pool))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"voided":        len(voidedIDs),
+		"challenge_ids": voidedIDs,
+		"refunded":      engine.RoundToCents(refunded),
+		"pool_amount":   pool.Amount,
+		"mega_jackpot":  pool.MegaJackpot,
+	})
+}
+
+// voidChallenge takes the entry's contribution back out of the pool and
+// mega-jackpot, returns the rake from house revenue and refunds the entry fee
+// to the player's balance, all in tx. Pay-and-play entries are refunded the
+// same way, so the refund never depends on a payment processor. A winner's
+// payout, and any mega-jackpot, goes back into the pool and is taken from
+// the player's balance, which may go negative. It returns
+// the log entry for the void, which the caller writes at the end of the
+// transaction, and the amount refunded. If the contribution was already paid
+// out to a later winner the house covers the shortfall. The caller must hold
+// row locks on the challenge and the pool.
+func voidChallenge(tx *gorm.DB, challenge *models.Challenge, pool *models.ChallengePool, reason string, now time.Time) (models.GameLog, float64, error) {
+	contribution := engine.RoundToCents(CHALLENGE_COST - challenge.Rake - challenge.JackpotContribution)
+	shortfall := 0.0
+
+	// A winner took the pool with its contribution in it, so put the payout
+	// back before taking the contribution out
+	reversed := 0.0
+	if challenge.IsWinner {
+		reversed = engine.RoundToCents(challenge.Amount + challenge.JackpotAmount)
+		pool.Amount = engine.RoundToCents(pool.Amount + challenge.Amount)
+		pool.MegaJackpot = engine.RoundToCents(pool.MegaJackpot + challenge.JackpotAmount)
+	}
+
+	pool.Amount = engine.RoundToCents(pool.Amount - contribution)
+	if pool.Amount < 0 {
+		shortfall -= pool.Amount
+		pool.Amount = 0
+	}
+	pool.MegaJackpot = engine.RoundToCents(pool.MegaJackpot - challenge.JackpotContribution)
+	if pool.MegaJackpot < 0 {
+		shortfall -= pool.MegaJackpot
+		pool.MegaJackpot = 0
+	}
+	if err := tx.Save(pool).Error; err != nil {
+		return models.GameLog{}, 0, err
+	}
+
+	if houseCost := engine.RoundToCents(challenge.Rake + shortfall); houseCost > 0 {
+		revenue := models.HouseRevenue{
+			ChallengeID: challenge.ID,
+			Type:        models.HouseRevenueVoid,
+			Amount:      -houseCost,
+		}
+		if err := tx.Create(&revenue).Error; err != nil {
+			return models.GameLog{}, 0, err
+		}
+	}
+
+	challenge.Voided = true
+	challenge.VoidedAt = &now
+	challenge.VoidReason = reason
+	if err := tx.Save(challenge).Error; err != nil {
+		return models.GameLog{}, 0, err
+	}
+
+	refund := CHALLENGE_COST
+	if _, err := creditWallet(tx, challenge.PlayerID, refund, models.WalletChallengeRefund,
+		fmt.Sprintf("challenge:%d", challenge.ID)); err != nil {
+		return models.GameLog{}, 0, err
+	}
+	if reversed > 0 {
+		if _, err := creditWallet(tx, challenge.PlayerID, -reversed, models.WalletChallengeWinReversal,
+			fmt.Sprintf("challenge:%d", challenge.ID)); err != nil {
+			return models.GameLog{}, 0, err
+		}
+	}
+
+	entry, err := gamelog.NewEntry(challenge.PlayerID, models.ActionChallengeEnd,
+		models.LogDetails{
+			"challenge_id": challenge.ID,
+			"amount":       0.0,
+			"voided":       true,
+			"reason":       reason,
+			"refund":       refund,
+			"reversed":     reversed,
+		})
+	return entry, refund, err
+}
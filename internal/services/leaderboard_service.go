@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"interview_Ping_20241219/internal/cache"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	BoardBiggestWins = "biggest-wins"
+	BoardMostWins    = "most-wins"
+	BoardMostEntries = "most-entries"
+
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+	PeriodAll  = "all"
+
+	LEADERBOARD_SIZE      = 100
+	LEADERBOARD_CACHE_TTL = 30 // seconds
+)
+
+func RegisterLeaderboardRoutes(router *gin.Engine) {
+	leaderboards := router.Group("/leaderboards")
+	{
+		leaderboards.GET("/:board", GetLeaderboard)
+	}
+}
+
+// LeaderboardEntry is a player's standing. Ties go to whoever reached the
+// score first (AchievedAt), then to the lower player ID.
+type LeaderboardEntry struct {
+	Rank       int       `json:"rank"`
+	PlayerID   uint      `json:"player_id"`
+	PlayerName string    `json:"player_name"`
+	Score      float64   `json:"score"`
+	AchievedAt time.Time `json:"achieved_at"`
+}
+
+// GetLeaderboard handles GET /leaderboards/:board?period=day|week|all&level=&limit=
+func GetLeaderboard(c *gin.Context) {
+	board := c.Param("board")
+	period := c.DefaultQuery("period", PeriodAll)
+	levelStr := c.Query("level")
+	limitStr := c.DefaultQuery("limit", "10")
+
+	if board != BoardBiggestWins && board != BoardMostWins && board != BoardMostEntries {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Leaderboard not found",
+		})
+		return
+	}
+
+	since, ok := periodStart(period, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid period parameter. Use day, week or all",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > LEADERBOARD_SIZE {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid limit parameter. Use 1-%d", LEADERBOARD_SIZE),
+		})
+		return
+	}
+
+	var level *uint
+	if levelStr != "" {
+		parsed, err := strconv.ParseUint(levelStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid level parameter",
+				"details": err.Error(),
+			})
+			return
+		}
+		value := uint(parsed)
+		level = &value
+	}
+
+	key := leaderboardCacheKey(board, period, since, level)
+	source := "cache"
+	entries, err := readLeaderboardCache(key, limit)
+	if err != nil || entries == nil {
+		source = "database"
+		entries, err = queryLeaderboard(board, since, level)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to fetch leaderboard",
+				"details": err.Error(),
+			})
+			return
+		}
+		writeLeaderboardCache(key, entries)
+		if len(entries) > limit {
+			entries = entries[:limit]
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"board":   board,
+		"period":  period,
+		"since":   since,
+		"source":  source,
+		"entries": entries,
+	})
+}
+
+// periodStart returns the beginning of the leaderboard period containing now.
+// Weeks start on Monday; "all" returns the zero time.
+func periodStart(period string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case PeriodDay:
+		return today, true
+	case PeriodWeek:
+		offset := (int(today.Weekday()) + 6) % 7
+		return today.AddDate(0, 0, -offset), true
+	case PeriodAll:
+		return time.Time{}, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// queryLeaderboard ranks players by score. A win counts its pool and any
+// mega-jackpot; a player's biggest win was achieved by the earliest of their
+// wins with that total, and a count when its last challenge was played.
+func queryLeaderboard(board string, since time.Time, level *uint) ([]LeaderboardEntry, error) {
+	query := database.DB.Model(&models.Challenge{}).
+		Joins("JOIN players ON players.id = challenges.player_id").
+		Where("challenges.voided = ?", false)
+	if board != BoardMostEntries {
+		query = query.Where("challenges.is_winner = ?", true)
+	}
+	if !since.IsZero() {
+		query = query.Where("challenges.created_at >= ?", since)
+	}
+	if level != nil {
+		query = query.Where("players.level = ?", *level)
+	}
+
+	if board == BoardBiggestWins {
+		query = database.DB.Table("(?) AS best", query.
+			Select("DISTINCT ON (challenges.player_id) challenges.player_id, players.name AS player_name, "+
+				"challenges.amount + COALESCE(challenges.jackpot_amount, 0) AS score, challenges.created_at AS achieved_at").
+			Order("challenges.player_id, score DESC, challenges.created_at"))
+	} else {
+		query = query.
+			Select("challenges.player_id, players.name AS player_name, COUNT(*) AS score, MAX(challenges.created_at) AS achieved_at").
+			Group("challenges.player_id, players.name")
+	}
+
+	var entries []LeaderboardEntry
+	if err := query.Order("score DESC, achieved_at, player_id").
+		Limit(LEADERBOARD_SIZE).
+		Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries, nil
+}
+
+func leaderboardCacheKey(board, period string, since time.Time, level *uint) string {
+	key := fmt.Sprintf("leaderboard:%s:%s:%d", board, period, since.Unix())
+	if level != nil {
+		key = fmt.Sprintf("%s:level:%d", key, *level)
+	}
+	return key
+}
+
+// leaderboardAchievedKey holds when each player on the cached board reached
+// their score, as Unix nanoseconds by player ID
+func leaderboardAchievedKey(key string) string {
+	return key + ":achieved"
+}
+
+// readLeaderboardCache returns nil entries on a cache miss
+func readLeaderboardCache(key string, limit int) ([]LeaderboardEntry, error) {
+	if cache.Redis == nil {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	exists, err := cache.Redis.Exists(ctx, key).Result()
+	if err != nil || exists == 0 {
+		return nil, err
+	}
+
+	members, err := cache.Redis.ZRevRangeWithScores(ctx, key, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	// Players tied with the last one may rank ahead of it, so read them all
+	if len(members) == limit {
+		lowest := strconv.FormatFloat(members[len(members)-1].Score, 'f', -1, 64)
+		members, err = cache.Redis.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{Min: lowest, Max: "+inf"}).Result()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(members))
+	playerIDs := make([]uint, 0, len(members))
+	fields := make([]string, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(fmt.Sprint(member.Member), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid leaderboard member %v: %w", member.Member, err)
+		}
+		entries = append(entries, LeaderboardEntry{PlayerID: uint(id), Score: member.Score})
+		playerIDs = append(playerIDs, uint(id))
+		fields = append(fields, fmt.Sprint(member.Member))
+	}
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	achieved, err := cache.Redis.HMGet(ctx, leaderboardAchievedKey(key), fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range achieved {
+		nanos, err := strconv.ParseInt(fmt.Sprint(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid achieved time for player %d: %v", entries[i].PlayerID, value)
+		}
+		entries[i].AchievedAt = time.Unix(0, nanos).UTC()
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		if !entries[i].AchievedAt.Equal(entries[j].AchievedAt) {
+			return entries[i].AchievedAt.Before(entries[j].AchievedAt)
+		}
+		return entries[i].PlayerID < entries[j].PlayerID
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	var players []models.Player
+	if err := database.DB.Where("id IN ?", playerIDs).Find(&players).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(players))
+	for _, player := range players {
+		names[player.ID] = player.Name
+	}
+
+	for i := range entries {
+		entries[i].Rank = i + 1
+		entries[i].PlayerName = names[entries[i].PlayerID]
+	}
+	return entries, nil
+}
+
+// writeLeaderboardCache stores the board as a sorted set of player IDs scored
+// by their board value, next to a hash of when each reached it, both expiring
+// after LEADERBOARD_CACHE_TTL. Empty boards are not cached.
+func writeLeaderboardCache(key string, entries []LeaderboardEntry) {
+	if cache.Redis == nil {
+		return
+	}
+
+	ctx := context.Background()
+	members := make([]redis.Z, 0, len(entries))
+	achieved := make(map[string]interface{}, len(entries))
+	for _, entry := range entries {
+		member := strconv.FormatUint(uint64(entry.PlayerID), 10)
+		members = append(members, redis.Z{Score: entry.Score, Member: member})
+		achieved[member] = entry.AchievedAt.UnixNano()
+	}
+
+	achievedKey := leaderboardAchievedKey(key)
+	_, err := cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key, achievedKey)
+		if len(members) > 0 {
+			pipe.ZAdd(ctx, key, members...)
+			pipe.HSet(ctx, achievedKey, achieved)
+			pipe.Expire(ctx, key, LEADERBOARD_CACHE_TTL*time.Second)
+			pipe.Expire(ctx, achievedKey, LEADERBOARD_CACHE_TTL*time.Second)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to cache leaderboard %s: %v", key, err)
+	}
+}
+
+// leaderboardCountScript adds ARGV[2] to a player's count on a cached board.
+// The cache holds only the top LEADERBOARD_SIZE (ARGV[4]), so a full board
+// without the player is dropped to be rebuilt, since their count is unknown.
+var leaderboardCountScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+if not redis.call("ZSCORE", KEYS[1], ARGV[1]) and redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[4]) then
+	return redis.call("DEL", KEYS[1], KEYS[2])
+end
+redis.call("ZINCRBY", KEYS[1], ARGV[2], ARGV[1])
+redis.call("HSET", KEYS[2], ARGV[1], ARGV[3])
+return 1
+`)
+
+// leaderboardBestScript raises a player's best score on a cached board to
+// ARGV[2], keeping the time of an earlier equal score. A player missing from
+// a full board was below all of it, so a new best only counts if it is not.
+var leaderboardBestScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+local current = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if current then
+	if tonumber(ARGV[2]) <= tonumber(current) then
+		return 0
+	end
+elseif redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[4]) then
+	local lowest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+	if tonumber(ARGV[2]) <= tonumber(lowest[2]) then
+		return 0
+	end
+end
+redis.call("ZADD", KEYS[1], ARGV[2], ARGV[1])
+redis.call("HSET", KEYS[2], ARGV[1], ARGV[3])
+return 1
+`)
+
+// updateLeaderboardCache applies a new challenge to every cached board it
+// counts on, for each period and with and without the player's level. Boards
+// that are not cached are left for the next read to build.
+func updateLeaderboardCache(player models.Player, challenge models.Challenge) {
+	if cache.Redis == nil {
+		return
+	}
+
+	ctx := context.Background()
+	member := strconv.FormatUint(uint64(player.ID), 10)
+	achieved := challenge.CreatedAt.UnixNano()
+	for _, period := range []string{PeriodDay, PeriodWeek, PeriodAll} {
+		since, _ := periodStart(period, challenge.CreatedAt)
+		for _, level := range []*uint{nil, &player.Level} {
+			key := leaderboardCacheKey(BoardMostEntries, period, since, level)
+			err := leaderboardCountScript.Run(ctx, cache.Redis, []string{key, leaderboardAchievedKey(key)},
+				member, 1, achieved, LEADERBOARD_SIZE).Err()
+			if err == nil && challenge.IsWinner {
+				key = leaderboardCacheKey(BoardMostWins, period, since, level)
+				err = leaderboardCountScript.Run(ctx, cache.Redis, []string{key, leaderboardAchievedKey(key)},
+					member, 1, achieved, LEADERBOARD_SIZE).Err()
+			}
+			if err == nil && challenge.IsWinner {
+				key = leaderboardCacheKey(BoardBiggestWins, period, since, level)
+				err = leaderboardBestScript.Run(ctx, cache.Redis, []string{key, leaderboardAchievedKey(key)},
+					member, challenge.Amount+challenge.JackpotAmount, achieved, LEADERBOARD_SIZE).Err()
+			}
+			if err != nil {
+				log.Printf("Failed to update leaderboard %s: %v", key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LogActionRequest struct {
+	Code   models.LogActionType  `json:"code" binding:"required"`
+	Names  models.LocalizedNames `json:"names" binding:"required"`
+	Fields models.LogFields      `json:"fields"`
+}
+
+// LogActionResponse is a registered action with its name in the requested
+// locale
+type LogActionResponse struct {
+	models.LogAction
+	Name       string `json:"name"`
+	ServerOnly bool   `json:"server_only"`
+}
+
+// requestLocale reads the locale query parameter, falling back to the first
+// language in Accept-Language and then English
+func requestLocale(c *gin.Context) string {
+	if locale := c.Query("locale"); locale != "" {
+		return locale
+	}
+	if header := c.GetHeader("Accept-Language"); header != "" {
+		first, _, _ := strings.Cut(header, ",")
+		locale, _, _ := strings.Cut(first, ";")
+		if locale = strings.TrimSpace(locale); locale != "" && locale != "*" {
+			return locale
+		}
+	}
+	return "en"
+}
+
+func logActionResponse(action models.LogAction, locale string) LogActionResponse {
+	return LogActionResponse{
+		LogAction:  action,
+		Name:       gamelog.DisplayName(action, locale),
+		ServerOnly: gamelog.IsServerAction(action.Code),
+	}
+}
+
+// ListLogActions handles GET /logs/actions?locale=
+func ListLogActions(c *gin.Context) {
+	locale := requestLocale(c)
+
+	registered := gamelog.Actions()
+	actions := make([]LogActionResponse, len(registered))
+	for i, action := range registered {
+		actions[i] = logActionResponse(action, locale)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"locale":  locale,
+		"actions": actions,
+	})
+}
+
+// CreateLogAction handles POST /logs/actions, registering a new action type
+// that clients can report through POST /logs
+func CreateLogAction(c *gin.Context) {
+	var req LogActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	action, err := gamelog.RegisterAction(database.DB, models.LogAction{
+		Code:   req.Code,
+		Names:  req.Names,
+		Fields: req.Fields,
+	})
+	if errors.Is(err, gamelog.ErrInvalidAction) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid action",
+			"details": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, gamelog.ErrActionExists) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Action already exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to register action",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, logActionResponse(action, requestLocale(c)))
+}
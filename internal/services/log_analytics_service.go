@@ -0,0 +1,399 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ANALYTICS_DEFAULT_DAYS = 30
+	ANALYTICS_MAX_DAYS     = 366
+	ANALYTICS_MAU_DAYS     = 30
+
+	// A login or room entry is only paired with a logout or leave within this
+	// long; anything later is counted as an open session
+	ANALYTICS_MAX_PAIR_DURATION = 24 * time.Hour
+)
+
+// analyticsRange is the span of local days an analytics request covers.
+// Start and End are the instants the first day begins and the day after the
+// last one begins in the requested timezone.
+type analyticsRange struct {
+	TZ        string
+	StartDate string
+	EndDate   string
+	Start     time.Time
+	End       time.Time
+	Days      []string
+}
+
+// parseAnalyticsRange reads start_date and end_date (YYYY-MM-DD, inclusive,
+// defaulting to the last 30 days) and tz (an IANA name, default UTC)
+func parseAnalyticsRange(c *gin.Context, now time.Time) (analyticsRange, error) {
+	var r analyticsRange
+
+	r.TZ = c.DefaultQuery("tz", "UTC")
+	if r.TZ == "" || r.TZ == "Local" {
+		return r, errors.New("Invalid tz parameter. Use an IANA time zone such as Asia/Taipei")
+	}
+	loc, err := time.LoadLocation(r.TZ)
+	if err != nil {
+		return r, errors.New("Invalid tz parameter. Use an IANA time zone such as Asia/Taipei")
+	}
+
+	today := now.In(loc)
+	endDay := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	if endDate := c.Query("end_date"); endDate != "" {
+		if endDay, err = time.ParseInLocation("2006-01-02", endDate, loc); err != nil {
+			return r, errors.New("Invalid end_date format. Use YYYY-MM-DD")
+		}
+	}
+	startDay := endDay.AddDate(0, 0, 1-ANALYTICS_DEFAULT_DAYS)
+	if startDate := c.Query("start_date"); startDate != "" {
+		if startDay, err = time.ParseInLocation("2006-01-02", startDate, loc); err != nil {
+			return r, errors.New("Invalid start_date format. Use YYYY-MM-DD")
+		}
+	}
+	if endDay.Before(startDay) {
+		return r, errors.New("end_date must not be before start_date")
+	}
+
+	for day := startDay; !day.After(endDay); day = day.AddDate(0, 0, 1) {
+		r.Days = append(r.Days, day.Format("2006-01-02"))
+	}
+	if len(r.Days) > ANALYTICS_MAX_DAYS {
+		return r, fmt.Errorf("Date range is too long. Use at most %d days", ANALYTICS_MAX_DAYS)
+	}
+
+	r.StartDate = r.Days[0]
+	r.EndDate = r.Days[len(r.Days)-1]
+	r.Start = startDay
+	r.End = endDay.AddDate(0, 0, 1)
+	return r, nil
+}
+
+func roundRatio(numerator, denominator int64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return math.Round(float64(numerator)/float64(denominator)*10000) / 10000
+}
+
+func roundSeconds(seconds *float64) *float64 {
+	if seconds == nil {
+		return nil
+	}
+	rounded := math.Round(*seconds*10) / 10
+	return &rounded
+}
+
+type DailyActivePlayers struct {
+	Date string `json:"date"`
+	DAU  int64  `json:"dau"`
+	MAU  int64  `json:"mau"`
+}
+
+// GetActivePlayers handles GET /logs/analytics/active. DAU counts players who
+// logged in on the day; MAU counts those who logged in during the 30 days
+// ending on it.
+func GetActivePlayers(c *gin.Context) {
+	r, err := parseAnalyticsRange(c, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var days []DailyActivePlayers
+	err = database.DB.Raw(`
+WITH days AS (
+	SELECT generate_series(?::date, ?::date, interval '1 day')::date AS day
+), logins AS (
+	SELECT DISTINCT player_id, (created_at AT TIME ZONE ?)::date AS day
+	FROM game_logs
+	WHERE action = ? AND created_at >= ? AND created_at < ?
+)
+SELECT TO_CHAR(days.day, 'YYYY-MM-DD') AS date,
+	COUNT(DISTINCT logins.player_id) FILTER (WHERE logins.day = days.day) AS dau,
+	COUNT(DISTINCT logins.player_id) AS mau
+FROM days
+LEFT JOIN logins ON logins.day > days.day - ? AND logins.day <= days.day
+GROUP BY days.day
+ORDER BY days.day`,
+		r.StartDate, r.EndDate,
+		r.TZ, models.ActionLogin, r.Start.AddDate(0, 0, 1-ANALYTICS_MAU_DAYS), r.End,
+		ANALYTICS_MAU_DAYS).
+		Scan(&days).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to compute active players",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var active int64
+	if err := database.DB.Model(&models.GameLog{}).
+		Where("action = ? AND created_at >= ? AND created_at < ?", models.ActionLogin, r.Start, r.End).
+		Distinct("player_id").
+		Count(&active).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to compute active players",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tz":             r.TZ,
+		"start_date":     r.StartDate,
+		"end_date":       r.EndDate,
+		"days":           days,
+		"active_players": active,
+	})
+}
+
+// DailyDurations summarizes the paired intervals (sessions or room visits)
+// that started on a day. Open counts starts without a matching end.
+type DailyDurations struct {
+	Date          string   `json:"date"`
+	Started       int64    `json:"started"`
+	Completed     int64    `json:"completed"`
+	Open          int64    `json:"open"`
+	TotalSeconds  float64  `json:"total_seconds"`
+	AvgSeconds    *float64 `json:"avg_seconds"`
+	MedianSeconds *float64 `json:"median_seconds"`
+}
+
+// pairedDurations pairs each startAction log with the same player's next
+// startAction or endAction log when that is an endAction within
+// ANALYTICS_MAX_PAIR_DURATION. With sameRoom the pair must also share
+// details.room_id. Results are bucketed by the local day of the start.
+func pairedDurations(r analyticsRange, startAction, endAction models.LogActionType, sameRoom bool, roomID *int64) ([]DailyDurations, error) {
+	startFilter := ""
+	pairCondition := "next_action = @end AND next_at - created_at <= @max_pair * interval '1 second'"
+	args := map[string]interface{}{
+		"tz":       r.TZ,
+		"start":    startAction,
+		"end":      endAction,
+		"from":     r.Start,
+		"to":       r.End,
+		"until":    r.End.Add(ANALYTICS_MAX_PAIR_DURATION),
+		"max_pair": int64(ANALYTICS_MAX_PAIR_DURATION.Seconds()),
+	}
+	if sameRoom {
+		pairCondition += " AND next_room = room"
+	}
+	if roomID != nil {
+		startFilter = "AND room = @room"
+		args["room"] = strconv.FormatInt(*roomID, 10)
+	}
+
+	var rows []DailyDurations
+	err := database.DB.Raw(fmt.Sprintf(`
+WITH events AS (
+	SELECT action, created_at, details->>'room_id' AS room,
+		LEAD(action) OVER w AS next_action,
+		LEAD(created_at) OVER w AS next_at,
+		LEAD(details->>'room_id') OVER w AS next_room
+	FROM game_logs
+	WHERE action IN (@start, @end) AND created_at >= @from AND created_at < @until
+	WINDOW w AS (PARTITION BY player_id ORDER BY created_at, id)
+), starts AS (
+	SELECT created_at,
+		CASE WHEN %s THEN EXTRACT(EPOCH FROM next_at - created_at)::float8 END AS seconds
+	FROM events
+	WHERE action = @start AND created_at < @to %s
+)
+SELECT TO_CHAR((created_at AT TIME ZONE @tz)::date, 'YYYY-MM-DD') AS date,
+	COUNT(*) AS started,
+	COUNT(seconds) AS completed,
+	COUNT(*) - COUNT(seconds) AS open,
+	COALESCE(SUM(seconds), 0) AS total_seconds,
+	AVG(seconds) AS avg_seconds,
+	PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY seconds) AS median_seconds
+FROM starts
+GROUP BY 1
+ORDER BY 1`, pairCondition, startFilter), args).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]DailyDurations, len(rows))
+	for _, row := range rows {
+		byDate[row.Date] = row
+	}
+	days := make([]DailyDurations, len(r.Days))
+	for i, date := range r.Days {
+		day := byDate[date]
+		day.Date = date
+		day.TotalSeconds = math.Round(day.TotalSeconds*10) / 10
+		day.AvgSeconds = roundSeconds(day.AvgSeconds)
+		day.MedianSeconds = roundSeconds(day.MedianSeconds)
+		days[i] = day
+	}
+	return days, nil
+}
+
+// GetSessionLengths handles GET /logs/analytics/sessions. A session is a login
+// followed by the same player's logout, with no other login in between.
+func GetSessionLengths(c *gin.Context) {
+	r, err := parseAnalyticsRange(c, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	days, err := pairedDurations(r, models.ActionLogin, models.ActionLogout, false, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to compute session lengths",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tz":         r.TZ,
+		"start_date": r.StartDate,
+		"end_date":   r.EndDate,
+		"days":       days,
+	})
+}
+
+// GetRoomDwellTimes handles GET /logs/analytics/rooms?room_id=. A visit is a
+// room entry followed by the same player leaving that room, with no other
+// room entry or exit in between.
+func GetRoomDwellTimes(c *gin.Context) {
+	r, err := parseAnalyticsRange(c, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var roomID *int64
+	if roomIDStr := c.Query("room_id"); roomIDStr != "" {
+		id, err := strconv.ParseInt(roomIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room_id parameter"})
+			return
+		}
+		roomID = &id
+	}
+
+	days, err := pairedDurations(r, models.ActionEnterRoom, models.ActionLeaveRoom, true, roomID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to compute room dwell times",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tz":         r.TZ,
+		"start_date": r.StartDate,
+		"end_date":   r.EndDate,
+		"room_id":    roomID,
+		"days":       days,
+	})
+}
+
+type DailyFunnel struct {
+	Date            string  `json:"date,omitempty"`
+	Registered      int64   `json:"registered"`
+	LoggedIn        int64   `json:"logged_in"`
+	JoinedChallenge int64   `json:"joined_challenge"`
+	LoginRate       float64 `json:"login_rate"`
+	JoinRate        float64 `json:"join_rate"`
+	Conversion      float64 `json:"conversion"`
+}
+
+// GetChallengeFunnel handles GET /logs/analytics/funnel. Players are grouped
+// by the day they registered and followed to their first login after
+// registering, then to their first challenge joined after that login.
+func GetChallengeFunnel(c *gin.Context) {
+	r, err := parseAnalyticsRange(c, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rows []DailyFunnel
+	err = database.DB.Raw(`
+WITH registered AS (
+	SELECT player_id, MIN(created_at) AS registered_at
+	FROM game_logs
+	WHERE action = @register AND created_at >= @from AND created_at < @to
+	GROUP BY player_id
+), steps AS (
+	SELECT registered.registered_at, login.at AS logged_in_at, joined.at AS joined_at
+	FROM registered
+	LEFT JOIN LATERAL (
+		SELECT MIN(created_at) AS at FROM game_logs
+		WHERE player_id = registered.player_id AND action = @login AND created_at >= registered.registered_at
+	) login ON true
+	LEFT JOIN LATERAL (
+		SELECT MIN(created_at) AS at FROM game_logs
+		WHERE player_id = registered.player_id AND action = @join AND created_at >= login.at
+	) joined ON true
+)
+SELECT TO_CHAR((registered_at AT TIME ZONE @tz)::date, 'YYYY-MM-DD') AS date,
+	COUNT(*) AS registered,
+	COUNT(logged_in_at) AS logged_in,
+	COUNT(joined_at) AS joined_challenge
+FROM steps
+GROUP BY 1
+ORDER BY 1`, map[string]interface{}{
+		"tz":       r.TZ,
+		"register": models.ActionRegister,
+		"login":    models.ActionLogin,
+		"join":     models.ActionJoinChallenge,
+		"from":     r.Start,
+		"to":       r.End,
+	}).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to compute challenge funnel",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	byDate := make(map[string]DailyFunnel, len(rows))
+	for _, row := range rows {
+		byDate[row.Date] = row
+	}
+	var total DailyFunnel
+	days := make([]DailyFunnel, len(r.Days))
+	for i, date := range r.Days {
+		day := byDate[date]
+		day.Date = date
+		day.LoginRate = roundRatio(day.LoggedIn, day.Registered)
+		day.JoinRate = roundRatio(day.JoinedChallenge, day.LoggedIn)
+		day.Conversion = roundRatio(day.JoinedChallenge, day.Registered)
+		days[i] = day
+
+		total.Registered += day.Registered
+		total.LoggedIn += day.LoggedIn
+		total.JoinedChallenge += day.JoinedChallenge
+	}
+	total.LoginRate = roundRatio(total.LoggedIn, total.Registered)
+	total.JoinRate = roundRatio(total.JoinedChallenge, total.LoggedIn)
+	total.Conversion = roundRatio(total.JoinedChallenge, total.Registered)
+
+	c.JSON(http.StatusOK, gin.H{
+		"tz":         r.TZ,
+		"start_date": r.StartDate,
+		"end_date":   r.EndDate,
+		"days":       days,
+		"total":      total,
+	})
+}
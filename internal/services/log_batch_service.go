@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"interview_Ping_20241219/internal/config"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// logBuffer backs the async mode of POST /logs/batch once StartLogBuffer runs
+var logBuffer *gamelog.BufferedWriter
+
+type BatchLogRequest struct {
+	Logs []models.GameLog `json:"logs" binding:"required"`
+}
+
+// BatchLogResult reports the outcome of one entry of a batch, by its index in
+// the request
+type BatchLogResult struct {
+	Index int    `json:"index"`
+	ID    uint   `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// StartLogBuffer starts the background writer used by async batch ingestion.
+// Calling it again is a no-op.
+func StartLogBuffer() {
+	if logBuffer != nil {
+		return
+	}
+	cfg := config.GetLogIngestConfig()
+	logBuffer = gamelog.NewBufferedWriter(database.DB, cfg.BufferSize, cfg.FlushSize, cfg.FlushInterval)
+	logBuffer.Start()
+}
+
+// CreateLogBatch handles POST /logs/batch. Entries are validated together,
+// with a single query for all player IDs, and the valid ones are chained and
+// written in one multi-row insert. With ?async=true they are handed to the buffered
+// writer instead and the response only says whether each was accepted.
+func CreateLogBatch(c *gin.Context) {
+	var req BatchLogRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	maxBatchSize := config.GetLogIngestConfig().MaxBatchSize
+	if len(req.Logs) == 0 || len(req.Logs) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("A batch must contain 1-%d logs", maxBatchSize),
+		})
+		return
+	}
+
+	async := c.Query("async") == "true"
+	if async && logBuffer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Async log ingestion is not enabled",
+		})
+		return
+	}
+
+	// Look up every referenced player at once
+	playerIDs := make([]uint, 0, len(req.Logs))
+	for _, entry := range req.Logs {
+		playerIDs = append(playerIDs, entry.PlayerID)
+	}
+	var existingIDs []uint
+	if err := database.DB.Model(&models.Player{}).Where("id IN ?", playerIDs).Pluck("id", &existingIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to validate players",
+			"details": err.Error(),
+		})
+		return
+	}
+	players := make(map[uint]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		players[id] = true
+	}
+
+	results := make([]BatchLogResult, len(req.Logs))
+	valid := make([]models.GameLog, 0, len(req.Logs))
+	validIndexes := make([]int, 0, len(req.Logs))
+	for i, entry := range req.Logs {
+		results[i].Index = i
+		entry.Action = models.NormalizeLogAction(string(entry.Action))
+		if err := validateBatchLog(entry, players); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		valid = append(valid, models.GameLog{
+			PlayerID: entry.PlayerID,
+			Action:   entry.Action,
+			Details:  entry.Details,
+		})
+		validIndexes = append(validIndexes, i)
+	}
+
+	if async {
+		for j, entry := range valid {
+			if !logBuffer.Enqueue(entry) {
+				results[validIndexes[j]].Error = "log buffer is full, retry later"
+			}
+		}
+	} else if len(valid) > 0 {
+		if err := gamelog.WriteBatch(database.DB, valid); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to create logs",
+				"details": err.Error(),
+			})
+			return
+		}
+		for j, entry := range valid {
+			results[validIndexes[j]].ID = entry.ID
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+
+	status := http.StatusCreated
+	if async {
+		status = http.StatusAccepted
+	}
+	if failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, gin.H{
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+		"results":   results,
+	})
+}
+
+// validateBatchLog applies the CreateLog checks to one batch entry, using the
+// set of player IDs already known to exist
+func validateBatchLog(entry models.GameLog, players map[uint]bool) error {
+	if entry.PlayerID == 0 {
+		return fmt.Errorf("player_id is required")
+	}
+	if entry.Action == "" {
+		return fmt.Errorf("action is required")
+	}
+	if !gamelog.IsValidAction(entry.Action) {
+		return fmt.Errorf("invalid action type")
+	}
+	if gamelog.IsServerAction(entry.Action) {
+		return fmt.Errorf("this action is recorded by the server and cannot be reported by clients")
+	}
+	if err := gamelog.ValidateDetails(entry.Action, entry.Details); err != nil {
+		return err
+	}
+	if !players[entry.PlayerID] {
+		return fmt.Errorf("player not found")
+	}
+	return nil
+}
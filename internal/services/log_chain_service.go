@@ -0,0 +1,94 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"interview_Ping_20241219/internal/config"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyLogs handles GET /logs/verify, checking every player's game log hash
+// chain against its tombstones and signed checkpoints
+func VerifyLogs(c *gin.Context) {
+	var publicKey ed25519.PublicKey
+	if key := config.GetLogSigningKey(); key != nil {
+		publicKey = key.Public().(ed25519.PublicKey)
+	}
+
+	report, err := gamelog.Verify(database.DB, publicKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to verify logs",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ListLogCheckpoints handles GET /logs/checkpoints, newest first
+func ListLogCheckpoints(c *gin.Context) {
+	var checkpoints []models.LogCheckpoint
+	if err := database.DB.Order("created_at DESC, id DESC").Limit(100).Find(&checkpoints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch checkpoints",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, checkpoints)
+}
+
+// CreateLogCheckpoint handles POST /logs/checkpoints, signing the chain heads
+// that moved without waiting for the scheduler
+func CreateLogCheckpoint(c *gin.Context) {
+	key := config.GetLogSigningKey()
+	if key == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Log signing is not configured",
+		})
+		return
+	}
+
+	checkpoints, err := gamelog.CreateCheckpoints(database.DB, key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create checkpoint",
+			"details": err.Error(),
+		})
+		return
+	}
+	if len(checkpoints) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No new log entries since the last checkpoint",
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, checkpoints)
+}
+
+// StartLogCheckpoints signs the chain heads on every interval while there is
+// something new to sign. It does nothing without a signing key.
+func StartLogCheckpoints(interval time.Duration) {
+	key := config.GetLogSigningKey()
+	if key == nil {
+		log.Printf("LOG_SIGNING_KEY is not set, game log checkpoints are disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := gamelog.CreateCheckpoints(database.DB, key); err != nil {
+				log.Printf("Failed to create game log checkpoints: %v", err)
+			}
+		}
+	}()
+}
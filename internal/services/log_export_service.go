@@ -0,0 +1,160 @@
+package services
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LOG_EXPORT_FLUSH_ROWS is how many rows are written between flushes to the client
+const LOG_EXPORT_FLUSH_ROWS = 500
+
+var logExportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+}
+
+// ExportLogs handles GET /logs/export?format=csv|ndjson. It takes the same
+// filters as GET /logs and streams every matching log, oldest first, straight
+// from a database cursor. Responses are gzipped when the client accepts it.
+func ExportLogs(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	contentType, ok := logExportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid format parameter. Use csv or ndjson",
+		})
+		return
+	}
+
+	query, err := filterLogs(c, database.DB.Model(&models.GameLog{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid details filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rows, err := query.Order("created_at, id").Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to export logs",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("game_logs_%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Vary", "Accept-Encoding")
+
+	var out io.Writer = c.Writer
+	flush := func() error { return nil }
+	if strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+		c.Header("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(c.Writer)
+		defer gz.Close()
+		out = gz
+		flush = gz.Flush
+	}
+	c.Status(http.StatusOK)
+
+	writer := newLogExportWriter(format, out)
+	count := 0
+	for rows.Next() {
+		var entry models.GameLog
+		if err := database.DB.ScanRows(rows, &entry); err != nil {
+			log.Printf("Log export stopped after %d rows: %v", count, err)
+			return
+		}
+		// The status is already sent, so a failure can only end the stream
+		if err := writer.Write(entry); err != nil {
+			log.Printf("Log export stopped after %d rows: %v", count, err)
+			return
+		}
+		count++
+		if count%LOG_EXPORT_FLUSH_ROWS == 0 {
+			if err := writer.Flush(); err != nil {
+				return
+			}
+			if err := flush(); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Log export stopped after %d rows: %v", count, err)
+	}
+	writer.Flush()
+}
+
+type logExportWriter interface {
+	Write(entry models.GameLog) error
+	// Flush writes out any buffered rows
+	Flush() error
+}
+
+func newLogExportWriter(format string, out io.Writer) logExportWriter {
+	if format == "ndjson" {
+		return &ndjsonLogWriter{encoder: json.NewEncoder(out)}
+	}
+	writer := csv.NewWriter(out)
+	writer.Write([]string{"id", "player_id", "action", "details", "created_at"})
+	return &csvLogWriter{writer: writer}
+}
+
+type ndjsonLogWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonLogWriter) Write(entry models.GameLog) error {
+	return w.encoder.Encode(gin.H{
+		"id":         entry.ID,
+		"player_id":  entry.PlayerID,
+		"action":     entry.Action,
+		"details":    entry.Details,
+		"created_at": entry.CreatedAt,
+	})
+}
+
+func (w *ndjsonLogWriter) Flush() error {
+	return nil
+}
+
+type csvLogWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvLogWriter) Write(entry models.GameLog) error {
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return err
+	}
+	return w.writer.Write([]string{
+		strconv.FormatUint(uint64(entry.ID), 10),
+		strconv.FormatUint(uint64(entry.PlayerID), 10),
+		string(entry.Action),
+		string(details),
+		entry.CreatedAt.Format(time.RFC3339Nano),
+	})
+}
+
+func (w *csvLogWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
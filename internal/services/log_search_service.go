@@ -0,0 +1,163 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	LOG_SEARCH_MAX_QUERY_LENGTH = 200
+
+	// The headline is built with private-use markers and a zero-width space
+	// between CJK characters, which are all replaced once the text is escaped
+	logHighlightStart     = "\uE000"
+	logHighlightStop      = "\uE001"
+	logHighlightSeparator = "\u200B"
+)
+
+var logHighlightOptions = fmt.Sprintf(
+	"StartSel=%s, StopSel=%s, MaxFragments=3, MaxWords=20, MinWords=5, FragmentDelimiter=\" … \"",
+	logHighlightStart, logHighlightStop)
+
+type LogSearchResult struct {
+	Log       models.GameLog `json:"log"`
+	Rank      float64        `json:"rank"`
+	Highlight string         `json:"highlight"`
+}
+
+type logSearchHit struct {
+	ID        uint
+	Rank      float64
+	Highlight string
+}
+
+// formatHighlight HTML-escapes a headline and marks the matches with <mark>
+func formatHighlight(headline string) string {
+	headline = strings.ReplaceAll(headline, logHighlightSeparator, "")
+	headline = html.EscapeString(headline)
+	headline = strings.ReplaceAll(headline, logHighlightStop+logHighlightStart, "")
+	headline = strings.ReplaceAll(headline, logHighlightStart, "<mark>")
+	return strings.ReplaceAll(headline, logHighlightStop, "</mark>")
+}
+
+// SearchLogs handles GET /logs/search?q= with the GET /logs filters. Matches
+// on the text in details are ranked by relevance and paged by limit and
+// offset.
+func SearchLogs(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "q is required",
+		})
+		return
+	}
+	if utf8.RuneCountInString(q) > LOG_SEARCH_MAX_QUERY_LENGTH {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("q must be at most %d characters", LOG_SEARCH_MAX_QUERY_LENGTH),
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(LOG_PAGE_DEFAULT_LIMIT)))
+	if err != nil || limit < 1 || limit > LOG_PAGE_MAX_LIMIT {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid limit parameter. Use 1-%d", LOG_PAGE_MAX_LIMIT),
+		})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid offset parameter",
+		})
+		return
+	}
+
+	query, err := filterLogs(c, database.DB.Model(&models.GameLog{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid details filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Rank and page the matches first so headlines are only built for the
+	// returned page. Fetch one extra row to know whether another page follows.
+	matches := query.
+		Select("game_logs.id, game_logs.details, game_logs.created_at, q, ts_rank_cd(game_logs.search_vector, q) AS rank").
+		Joins(fmt.Sprintf("CROSS JOIN websearch_to_tsquery('%s', ?) AS q", database.GAME_LOG_SEARCH_CONFIG), gamelog.SearchQuery(q)).
+		Where("game_logs.search_vector @@ q").
+		Order("rank DESC, game_logs.created_at DESC, game_logs.id DESC").
+		Limit(limit + 1).
+		Offset(offset)
+
+	var hits []logSearchHit
+	if err := database.DB.Table("(?) AS matches", matches).
+		Select(fmt.Sprintf("id, rank, ts_headline('%s', game_log_search_text(details, ?), q, ?) AS highlight", database.GAME_LOG_SEARCH_CONFIG),
+			logHighlightSeparator, logHighlightOptions).
+		Order("rank DESC, created_at DESC, id DESC").
+		Scan(&hits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to search logs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var nextOffset *int
+	if len(hits) > limit {
+		hits = hits[:limit]
+		next := offset + limit
+		nextOffset = &next
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	var logs []models.GameLog
+	if len(ids) > 0 {
+		if err := database.DB.Preload("Player").Where("id IN ?", ids).Find(&logs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to fetch logs",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+	byID := make(map[uint]models.GameLog, len(logs))
+	for _, log := range logs {
+		byID[log.ID] = log
+	}
+
+	results := make([]LogSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		log, ok := byID[hit.ID]
+		if !ok {
+			// Archived between the two queries
+			continue
+		}
+		results = append(results, LogSearchResult{
+			Log:       log,
+			Rank:      hit.Rank,
+			Highlight: formatHighlight(hit.Highlight),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":     results,
+		"limit":       limit,
+		"offset":      offset,
+		"next_offset": nextOffset,
+	})
+}
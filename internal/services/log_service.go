@@ -1,41 +1,81 @@
 package services
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
 	"interview_Ping_20241219/internal/models"
 	"net/http"
 	"strconv" // Add this import
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func RegisterLogRoutes(router *gin.Engine) {
 	logs := router.Group("/logs")
 	{
 		logs.GET("", GetLogs)
+		logs.GET("/export", ExportLogs)
+		logs.GET("/search", SearchLogs)
+		logs.GET("/stream", StreamLogs)
 		logs.POST("", CreateLog)
+		logs.POST("/batch", CreateLogBatch)
+		logs.GET("/actions", ListLogActions)
+		logs.POST("/actions", RequireAdmin(), CreateLogAction)
+		logs.GET("/verify", RequireAdmin(), VerifyLogs)
+		logs.GET("/checkpoints", ListLogCheckpoints)
+		logs.GET("/analytics/active", GetActivePlayers)
+		logs.GET("/analytics/sessions", GetSessionLengths)
+		logs.GET("/analytics/rooms", GetRoomDwellTimes)
+		logs.GET("/analytics/funnel", GetChallengeFunnel)
+		logs.POST("/checkpoints", RequireAdmin(), CreateLogCheckpoint)
 	}
 }
 
-// GetLogs handles GET /logs with query parameters
-func GetLogs(c *gin.Context) {
-	playerID := c.Query("player_id")
-	action := c.Query("action")
-	startTime := c.Query("start_time")
-	endTime := c.Query("end_time")
-	limitStr := c.DefaultQuery("limit", "50")
+const (
+	LOG_PAGE_DEFAULT_LIMIT = 50
+	LOG_PAGE_MAX_LIMIT     = 500
+)
 
-	// Convert limit string to integer
-	limit, err := strconv.Atoi(limitStr)
+// logCursor is the position after the last log of a page. It is handed to
+// clients base64-encoded so they treat it as opaque.
+type logCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"id"`
+}
+
+func encodeLogCursor(log models.GameLog) string {
+	b, _ := json.Marshal(logCursor{CreatedAt: log.CreatedAt, ID: log.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeLogCursor(s string) (logCursor, error) {
+	var cursor logCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid limit parameter",
-			"details": err.Error(),
-		})
-		return
+		return cursor, err
+	}
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return cursor, err
 	}
+	if cursor.ID == 0 {
+		return cursor, fmt.Errorf("cursor is missing its position")
+	}
+	return cursor, nil
+}
 
-	query := database.DB.Model(&models.GameLog{}).Preload("Player")
+// filterLogs applies the GET /logs filters (player_id, action, start_time,
+// end_time and details.<field>) to a game log query
+func filterLogs(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	playerID := c.Query("player_id")
+	action := models.NormalizeLogAction(c.Query("action"))
+	startTime := c.Query("start_time")
+	endTime := c.Query("end_time")
 
 	// Apply filters
 	if playerID != "" {
@@ -51,11 +91,94 @@ func GetLogs(c *gin.Context) {
 		query = query.Where("created_at <= ?", endTime)
 	}
 
-	// Apply limit and order
-	query = query.Order("created_at DESC").Limit(limit)
+	// Filters on detail fields, e.g. details.room_id=5, use JSONB containment
+	// so they are served by the GIN index on details
+	detailFilters := models.LogDetails{}
+	for key, values := range c.Request.URL.Query() {
+		name, ok := strings.CutPrefix(key, "details.")
+		if !ok {
+			continue
+		}
+		value, err := gamelog.ParseDetailFilter(action, name, values[0])
+		if err != nil {
+			return nil, err
+		}
+		detailFilters[name] = value
+	}
+	if len(detailFilters) > 0 {
+		query = query.Where("details @> ?::jsonb", detailFilters)
+	}
+
+	return query, nil
+}
+
+// GetLogs handles GET /logs with query parameters. Results are paged by an
+// opaque cursor on (created_at, id); pass next_cursor back as cursor to get
+// the following page.
+func GetLogs(c *gin.Context) {
+	limitStr := c.DefaultQuery("limit", strconv.Itoa(LOG_PAGE_DEFAULT_LIMIT))
 
+	// Convert limit string to integer
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > LOG_PAGE_MAX_LIMIT {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid limit parameter. Use 1-%d", LOG_PAGE_MAX_LIMIT),
+		})
+		return
+	}
+
+	order := c.DefaultQuery("order", "desc")
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid order parameter. Use asc or desc",
+		})
+		return
+	}
+
+	query, err := filterLogs(c, database.DB.Model(&models.GameLog{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid details filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response := gin.H{}
+	if c.Query("include_total") == "true" {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to count logs",
+				"details": err.Error(),
+			})
+			return
+		}
+		response["total"] = total
+	}
+
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cursor, err := decodeLogCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid cursor",
+				"details": err.Error(),
+			})
+			return
+		}
+		if order == "asc" {
+			query = query.Where("(created_at, id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+		} else {
+			query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+	}
+
+	// Fetch one extra row to know whether another page follows
 	var logs []models.GameLog
-	if err := query.Find(&logs).Error; err != nil {
+	if err := query.Preload("Player").
+		Order(fmt.Sprintf("created_at %s, id %s", order, order)).
+		Limit(limit + 1).
+		Find(&logs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to fetch logs",
 			"details": err.Error(),
@@ -63,7 +186,17 @@ func GetLogs(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, logs)
+	var nextCursor *string
+	if len(logs) > limit {
+		logs = logs[:limit]
+		cursor := encodeLogCursor(logs[limit-1])
+		nextCursor = &cursor
+	}
+
+	response["logs"] = logs
+	response["limit"] = limit
+	response["next_cursor"] = nextCursor
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateLog handles POST /logs
@@ -92,20 +225,27 @@ func CreateLog(c *gin.Context) {
 		return
 	}
 
-	// Validate action type
-	validActions := map[models.LogActionType]bool{
-		models.ActionRegister:      true,
-		models.ActionLogin:         true,
-		models.ActionLogout:        true,
-		models.ActionEnterRoom:     true,
-		models.ActionLeaveRoom:     true,
-		models.ActionJoinChallenge: true,
-		models.ActionChallengeEnd:  true,
+	// Validate action type, accepting the legacy Chinese values too
+	log.Action = models.NormalizeLogAction(string(log.Action))
+	if !gamelog.IsValidAction(log.Action) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid action type",
+		})
+		return
 	}
 
-	if !validActions[log.Action] {
+	if err := gamelog.ValidateDetails(log.Action, log.Details); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid action type",
+			"error":   "Invalid details",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Core events are logged by the services that perform them
+	if gamelog.IsServerAction(log.Action) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "This action is recorded by the server and cannot be reported by clients",
 		})
 		return
 	}
@@ -120,7 +260,8 @@ func CreateLog(c *gin.Context) {
 	}
 
 	// Create log entry
-	if err := database.DB.Create(&log).Error; err != nil {
+	created, err := gamelog.Write(database.DB, log.PlayerID, log.Action, log.Details)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to create log",
 			"details": err.Error(),
@@ -128,5 +269,5 @@ func CreateLog(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, log)
+	c.JSON(http.StatusCreated, created)
 }
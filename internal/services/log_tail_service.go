@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/events"
+	"interview_Ping_20241219/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	EventGameLog = "log"
+
+	LOG_TAIL_BUFFER_SIZE = 256
+)
+
+var startLogTailOnce sync.Once
+
+// LogTailEntry is a game log as announced by the database notification
+type LogTailEntry struct {
+	ID               uint                 `json:"id"`
+	PlayerID         uint                 `json:"player_id"`
+	Action           models.LogActionType `json:"action"`
+	Details          models.LogDetails    `json:"details,omitempty"`
+	DetailsTruncated bool                 `json:"details_truncated,omitempty"`
+	Seq              *uint64              `json:"seq,omitempty"`
+	CreatedAt        time.Time            `json:"created_at"`
+}
+
+// StartLogTail relays committed game logs from Postgres to events.Logs.
+// Each instance listens itself, so a tail connected to any instance sees
+// logs written by all of them. Calling it again is a no-op.
+func StartLogTail() {
+	startLogTailOnce.Do(func() {
+		go database.ListenGameLogs(context.Background(), func(payload string) {
+			var entry LogTailEntry
+			if err := json.Unmarshal([]byte(payload), &entry); err != nil {
+				log.Printf("Ignoring malformed game log notification: %v", err)
+				return
+			}
+			events.Logs.Publish(EventGameLog, entry)
+		})
+	})
+}
+
+// StreamLogs handles GET /logs/stream as Server-Sent Events, pushing new game
+// logs as they are committed. Takes the player_id and action filters of GET /logs.
+func StreamLogs(c *gin.Context) {
+	var playerID uint64
+	if value := c.Query("player_id"); value != "" {
+		var err error
+		if playerID, err = strconv.ParseUint(value, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid player_id parameter",
+			})
+			return
+		}
+	}
+	action := models.NormalizeLogAction(c.Query("action"))
+
+	sub := events.Logs.Subscribe(LOG_TAIL_BUFFER_SIZE)
+	defer events.Logs.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	// Send the headers now so clients know the tail is connected
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(FEED_HEARTBEAT)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped by the broker for falling behind
+				c.SSEvent("error", gin.H{"error": "Client too slow, please reconnect"})
+				c.Writer.Flush()
+				return
+			}
+			entry, _ := event.Data.(LogTailEntry)
+			if playerID != 0 && uint64(entry.PlayerID) != playerID {
+				continue
+			}
+			if action != "" && entry.Action != action {
+				continue
+			}
+			c.SSEvent(event.Type, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
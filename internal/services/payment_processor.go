@@ -10,6 +10,7 @@ import (
 // PaymentProcessor interface
 type PaymentProcessor interface {
 	Process(amount float64) (string, error)
+	Refund(transactionID string, amount float64) error
 }
 
 // CreditCardProcessor implements credit card payment processing
@@ -27,6 +28,16 @@ func (p *CreditCardProcessor) Process(amount float64) (string, error) {
 	return fmt.Sprintf("CC_%s_%d", generateCardToken(), time.Now().UnixNano()), nil
 }
 
+func (p *CreditCardProcessor) Refund(transactionID string, amount float64) error {
+	// Simulate credit card refund call
+	time.Sleep(time.Millisecond * 400)
+
+	if rand.Float64() < 0.02 {
+		return fmt.Errorf("credit card refund failed: gateway timeout")
+	}
+	return nil
+}
+
 func generateCardToken() string {
 	return fmt.Sprintf("CARD_%d", rand.Intn(10000))
 }
@@ -46,6 +57,16 @@ func (p *BankTransferProcessor) Process(amount float64) (string, error) {
 	return fmt.Sprintf("BT_%s_%d", generateBankToken(), time.Now().UnixNano()), nil
 }
 
+func (p *BankTransferProcessor) Refund(transactionID string, amount float64) error {
+	// Simulate bank refund call
+	time.Sleep(time.Millisecond * 500)
+
+	if rand.Float64() < 0.02 {
+		return fmt.Errorf("bank transfer refund failed: account closed")
+	}
+	return nil
+}
+
 func generateBankToken() string {
 	return fmt.Sprintf("BANK_%d", rand.Intn(10000))
 }
@@ -65,6 +86,16 @@ func (p *ThirdPartyProcessor) Process(amount float64) (string, error) {
 	return fmt.Sprintf("TP_%s_%d", generateTPToken(), time.Now().UnixNano()), nil
 }
 
+func (p *ThirdPartyProcessor) Refund(transactionID string, amount float64) error {
+	// Simulate third-party refund call
+	time.Sleep(time.Millisecond * 300)
+
+	if rand.Float64() < 0.02 {
+		return fmt.Errorf("third-party refund failed: service unavailable")
+	}
+	return nil
+}
+
 func generateTPToken() string {
 	return fmt.Sprintf("3RDPARTY_%d", rand.Intn(10000))
 }
@@ -84,6 +115,16 @@ func (p *BlockchainProcessor) Process(amount float64) (string, error) {
 	return fmt.Sprintf("BC_%s_%d", generateBlockchainHash(), time.Now().UnixNano()), nil
 }
 
+func (p *BlockchainProcessor) Refund(transactionID string, amount float64) error {
+	// Blockchain payments cannot be reversed on-chain; a refund is a new transfer
+	time.Sleep(time.Second * 1)
+
+	if rand.Float64() < 0.05 {
+		return fmt.Errorf("blockchain refund failed: network congestion")
+	}
+	return nil
+}
+
 func generateBlockchainHash() string {
 	const charset = "abcdef0123456789"
 	hash := make([]byte, 32)
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"interview_Ping_20241219/internal/cache"
 	"interview_Ping_20241219/internal/database"
 	"interview_Ping_20241219/internal/models"
 	"math/rand"
@@ -9,8 +12,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+var ErrPaymentDeclined = errors.New("payment declined")
+
 func RegisterPaymentRoutes(router *gin.Engine) {
 	payments := router.Group("/payments")
 	{
@@ -76,22 +82,91 @@ func ProcessPayment(c *gin.Context) {
 		return
 	}
 
+	// Hold the player lock from the limit check until the payment is recorded
+	// so concurrent deposits cannot both pass the check
+	ctx, cancel := context.WithTimeout(c.Request.Context(), PLAYER_LOCK_WAIT)
+	defer cancel()
+	unlock, err := lockPlayer(ctx, req.PlayerID)
+	if errors.Is(err, cache.ErrLockNotAcquired) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Another payment request for this player is in progress",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to lock player",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer unlock()
+
+	// Enforce responsible gaming restrictions and deposit limits
+	violation, err := checkDepositLimits(req.PlayerID, req.Amount, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to check deposit limits",
+			"details": err.Error(),
+		})
+		return
+	}
+	if violation != nil {
+		c.JSON(http.StatusForbidden, violation.response())
+		return
+	}
+
 	// Get payment processor
-	processor := CreatePaymentProcessor(req.Method)
-	if processor == nil {
+	if CreatePaymentProcessor(req.Method) == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid payment method",
 		})
 		return
 	}
 
+	payment, err := capturePayment(req.PlayerID, req.Amount, req.Method, req.Details)
+	if errors.Is(err, ErrPaymentDeclined) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Payment failed",
+			"details":    payment.ErrorMessage,
+			"payment_id": payment.ID,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to record payment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Payment processed successfully",
+		"payment_id":     payment.ID,
+		"transaction_id": payment.TransactionID,
+		"status":         payment.Status,
+		"amount":         payment.Amount,
+		"method":         payment.Method,
+	})
+}
+
+// capturePayment charges the player through the method's processor and
+// records the outcome. A declined charge is still saved, as a failed payment,
+// and reported as ErrPaymentDeclined.
+func capturePayment(playerID uint, amount float64, method models.PaymentMethod, details string) (models.Payment, error) {
+	processor := CreatePaymentProcessor(method)
+	if processor == nil {
+		return models.Payment{}, fmt.Errorf("unsupported payment method %q", method)
+	}
+
 	// Create initial payment record
 	payment := models.Payment{
-		PlayerID: req.PlayerID,
-		Amount:   req.Amount,
-		Method:   req.Method,
+		PlayerID: playerID,
+		Amount:   amount,
+		Method:   method,
 		Status:   models.PaymentStatusPending,
-		Details:  req.Details,
+		Details:  details,
 	}
 
 	// Start transaction
@@ -99,33 +174,20 @@ func ProcessPayment(c *gin.Context) {
 
 	if err := tx.Create(&payment).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create payment record",
-			"details": err.Error(),
-		})
-		return
+		return payment, err
 	}
 
 	// Process payment
-	transactionID, err := processor.Process(req.Amount)
+	transactionID, err := processor.Process(amount)
 	if err != nil {
 		payment.Status = models.PaymentStatusFailed
 		payment.ErrorMessage = err.Error()
 		if err := tx.Save(&payment).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to update payment record",
-				"details": err.Error(),
-			})
-			return
+			return payment, err
 		}
 		tx.Commit()
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "Payment failed",
-			"details":    err.Error(),
-			"payment_id": payment.ID,
-		})
-		return
+		return payment, fmt.Errorf("%w: %v", ErrPaymentDeclined, err)
 	}
 
 	// Update successful payment
@@ -134,22 +196,32 @@ func ProcessPayment(c *gin.Context) {
 
 	if err := tx.Save(&payment).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update payment record",
-			"details": err.Error(),
-		})
-		return
+		return payment, err
 	}
 
-	tx.Commit()
+	return payment, tx.Commit().Error
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":        "Payment processed successfully",
-		"payment_id":     payment.ID,
-		"transaction_id": transactionID,
-		"status":         payment.Status,
-		"amount":         payment.Amount,
-		"method":         payment.Method,
+// refundPayment compensates a captured payment whose purchase could not be
+// completed. If the processor cannot refund, the amount is credited to the
+// player's wallet instead so the player is never left out of pocket.
+func refundPayment(payment models.Payment, reason string) error {
+	refundErr := fmt.Errorf("unsupported payment method %q", payment.Method)
+	if processor := CreatePaymentProcessor(payment.Method); processor != nil {
+		refundErr = processor.Refund(payment.TransactionID, payment.Amount)
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		payment.Status = models.PaymentStatusRefunded
+		payment.ErrorMessage = reason
+		if refundErr != nil {
+			payment.ErrorMessage = fmt.Sprintf("%s; refund failed (%v), credited to wallet", reason, refundErr)
+			if _, err := creditWallet(tx, payment.PlayerID, payment.Amount, models.WalletPaymentCompensation,
+				fmt.Sprintf("payment:%d", payment.ID)); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&payment).Error
 	})
 }
 
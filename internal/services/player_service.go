@@ -4,6 +4,7 @@ import (
     "net/http"
     "github.com/gin-gonic/gin"
     "interview_Ping_20241219/internal/database"
+    "interview_Ping_20241219/internal/gamelog"
     "interview_Ping_20241219/internal/models"
 )
 
@@ -25,6 +26,8 @@ func RegisterPlayerRoutes(router *gin.Engine) {
         players.GET("/:id", GetPlayer)
         players.PUT("/:id", UpdatePlayer)
         players.DELETE("/:id", DeletePlayer)
+        players.GET("/:id/challenges", GetPlayerChallenges)
+        players.GET("/:id/challenge-stats", GetPlayerChallengeStats)
     }
 }
 
@@ -78,7 +81,10 @@ func CreatePlayer(c *gin.Context) {
         Level: req.Level,
     }
 
-    if err := database.DB.Create(&player).Error; err != nil {
+    tx := database.DB.Begin()
+
+    if err := tx.Create(&player).Error; err != nil {
+        tx.Rollback()
         c.JSON(http.StatusInternalServerError, gin.H{
             "error": "Failed to create player",
             "details": err.Error(),
@@ -86,6 +92,18 @@ func CreatePlayer(c *gin.Context) {
         return
     }
 
+    if _, err := gamelog.Write(tx, player.ID, models.ActionRegister,
+        models.LogDetails{"name": player.Name, "level": player.Level}); err != nil {
+        tx.Rollback()
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "error": "Failed to create player",
+            "details": err.Error(),
+        })
+        return
+    }
+
+    tx.Commit()
+
     c.JSON(http.StatusCreated, player)
 }
 
@@ -110,10 +128,13 @@ func UpdatePlayer(c *gin.Context) {
         return
     }
     
+    // Balance only changes through wallet transactions
+    balance := player.Balance
     if err := c.ShouldBindJSON(&player); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
+    player.Balance = balance
     
     database.DB.Save(&player)
     c.JSON(http.StatusOK, player)
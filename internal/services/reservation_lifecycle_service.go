@@ -0,0 +1,307 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview_Ping_20241219/internal/config"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReservationNotConfirmed = errors.New("only confirmed reservations can be changed")
+	ErrReservationStarted      = errors.New("reservation has already started")
+	ErrCheckInClosed           = errors.New("check-in is not open for this reservation")
+	ErrReservationMoveCutoff   = errors.New("reservation starts within the cancellation cutoff and can no longer be moved")
+	ErrReservationMoveToPast   = errors.New("reservation cannot be moved to a time that has already started")
+	ErrReservationMoveInCutoff = errors.New("reservation cannot be moved to start within the cancellation cutoff")
+)
+
+// ReservationUpdateRequest moves a reservation. Omitted fields keep their
+// current value.
+type ReservationUpdateRequest struct {
+	Date      string `json:"date"`       // Format: "2006-01-02"
+	StartTime string `json:"start_time"` // Format: "15:04"
+	EndTime   string `json:"end_time"`   // Format: "15:04"
+}
+
+func reservationError(c *gin.Context, err error, room models.Room, message string) {
+	switch {
+	case errors.Is(err, ErrReservationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reservation not found"})
+	case errors.Is(err, ErrRoomNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Room not found"})
+	case errors.Is(err, ErrReservationConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Room is already reserved for this time period"})
+	case errors.Is(err, ErrRoomClosed):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("Room is only open from %s to %s UTC", room.OpensAt, room.ClosesAt),
+		})
+	case errors.Is(err, ErrRoomMaintenanceScheduled):
+		c.JSON(http.StatusConflict, gin.H{"error": "Room is scheduled for maintenance during this time period"})
+	case errors.Is(err, ErrReservationNotConfirmed), errors.Is(err, ErrReservationStarted),
+		errors.Is(err, ErrCheckInClosed), errors.Is(err, ErrReservationMoveCutoff),
+		errors.Is(err, ErrReservationMoveToPast), errors.Is(err, ErrReservationMoveInCutoff):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+func lockReservation(tx *gorm.DB, id string) (models.Reservation, error) {
+	var reservation models.Reservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reservation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return reservation, ErrReservationNotFound
+		}
+		return reservation, err
+	}
+	return reservation, nil
+}
+
+// GetReservation handles GET /reservations/:id
+func GetReservation(c *gin.Context) {
+	var reservation models.Reservation
+	if err := database.DB.Preload("Room").Preload("Player").First(&reservation, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Reservation not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// UpdateReservation handles PATCH /reservations/:id. Confirmed reservations
+// can be moved to other times in the same room until the cancellation cutoff,
+// so a move cannot be used to cancel late for free. The new time has to be
+// outside the cutoff too, as it would be for a new booking that could still
+// be cancelled.
+func UpdateReservation(c *gin.Context) {
+	var req ReservationUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var current models.Reservation
+	if err := database.DB.First(&current, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Reservation not found",
+		})
+		return
+	}
+
+	date, startTime, endTime := current.Date.UTC(), current.StartTime.UTC(), current.EndTime.UTC()
+	var err error
+	if req.Date != "" {
+		if date, err = time.Parse("2006-01-02", req.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid date format. Use YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if req.StartTime != "" {
+		if startTime, err = time.Parse("15:04", req.StartTime); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid start time format. Use HH:mm",
+			})
+			return
+		}
+	}
+	if req.EndTime != "" {
+		if endTime, err = time.Parse("15:04", req.EndTime); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid end time format. Use HH:mm",
+			})
+			return
+		}
+	}
+	if !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "end_time must be after start_time",
+		})
+		return
+	}
+
+	// Lock the room before the reservation, in the same order as booking
+	var room models.Room
+	var reservation models.Reservation
+	cfg := config.GetReservationConfig()
+	now := time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if room, err = lockRoom(tx, current.RoomID); err != nil {
+			return err
+		}
+		if reservation, err = lockReservation(tx, c.Param("id")); err != nil {
+			return err
+		}
+		if reservation.Status != models.ReservationConfirmed {
+			return ErrReservationNotConfirmed
+		}
+		start, _ := reservationSpan(reservation.Date, reservation.StartTime, reservation.EndTime)
+		if !now.Before(start) {
+			return ErrReservationStarted
+		}
+		if start.Sub(now) < cfg.CancelCutoff {
+			return ErrReservationMoveCutoff
+		}
+		newStart, _ := reservationSpan(date, startTime, endTime)
+		if !now.Before(newStart) {
+			return ErrReservationMoveToPast
+		}
+		if newStart.Sub(now) < cfg.CancelCutoff {
+			return ErrReservationMoveInCutoff
+		}
+		if err := checkReservationSlot(tx, room, reservation.ID, date, startTime, endTime, now); err != nil {
+			return err
+		}
+
+		reservation.Date, reservation.StartTime, reservation.EndTime = date, startTime, endTime
+		return tx.Model(&reservation).Select("date", "start_time", "end_time", "updated_at").Updates(&reservation).Error
+	})
+	if err != nil {
+		reservationError(c, err, room, "Failed to update reservation")
+		return
+	}
+
+	database.DB.Preload("Room").Preload("Player").First(&reservation, reservation.ID)
+	c.JSON(http.StatusOK, reservation)
+}
+
+// CancelReservation handles POST /reservations/:id/cancel. Cancelling closer
+// to the start than the cancellation cutoff takes the late cancellation fee
+// from the player's wallet, which can leave a negative balance.
+func CancelReservation(c *gin.Context) {
+	cfg := config.GetReservationConfig()
+	now := time.Now()
+
+	var reservation models.Reservation
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if reservation, err = lockReservation(tx, c.Param("id")); err != nil {
+			return err
+		}
+		if reservation.Status != models.ReservationConfirmed {
+			return ErrReservationNotConfirmed
+		}
+		start, _ := reservationSpan(reservation.Date, reservation.StartTime, reservation.EndTime)
+		if !now.Before(start) {
+			return ErrReservationStarted
+		}
+
+		reservation.Status = models.ReservationCancelled
+		reservation.CancelledAt = &now
+		if start.Sub(now) < cfg.CancelCutoff {
+			reservation.CancellationFee = cfg.LateCancelFee
+		}
+		if err := tx.Model(&reservation).Select("status", "cancelled_at", "cancellation_fee", "updated_at").
+			Updates(&reservation).Error; err != nil {
+			return err
+		}
+
+		if reservation.CancellationFee > 0 {
+			if _, err := creditWallet(tx, reservation.PlayerID, -reservation.CancellationFee, models.WalletReservationFee,
+				fmt.Sprintf("reservation:%d", reservation.ID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		reservationError(c, err, models.Room{}, "Failed to cancel reservation")
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// CheckInReservation handles POST /reservations/:id/check-in, open from
+// shortly before the start until the end of the reservation
+func CheckInReservation(c *gin.Context) {
+	cfg := config.GetReservationConfig()
+	now := time.Now()
+
+	var reservation models.Reservation
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if reservation, err = lockReservation(tx, c.Param("id")); err != nil {
+			return err
+		}
+		if reservation.Status != models.ReservationConfirmed {
+			return ErrReservationNotConfirmed
+		}
+		start, end := reservationSpan(reservation.Date, reservation.StartTime, reservation.EndTime)
+		if now.Before(start.Add(-cfg.CheckInEarly)) || !now.Before(end) {
+			return ErrCheckInClosed
+		}
+
+		reservation.Status = models.ReservationCheckedIn
+		reservation.CheckedInAt = &now
+		return tx.Model(&reservation).Select("status", "checked_in_at", "updated_at").Updates(&reservation).Error
+	})
+	if err != nil {
+		reservationError(c, err, models.Room{}, "Failed to check in")
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// StartReservationScheduler closes reservations once they end: checked-in
+// ones are completed and confirmed ones become no-shows. Each update is
+// guarded by the status it expects, so running it on several app instances
+// is safe.
+func StartReservationScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			closeEndedReservations(time.Now())
+		}
+	}()
+}
+
+func closeEndedReservations(now time.Time) {
+	var open []models.Reservation
+	if err := database.DB.Where("status IN ? AND date <= ?",
+		[]models.ReservationStatus{models.ReservationConfirmed, models.ReservationCheckedIn}, now.UTC()).
+		Find(&open).Error; err != nil {
+		log.Printf("Failed to fetch open reservations: %v", err)
+		return
+	}
+
+	ended := map[models.ReservationStatus][]uint{}
+	for _, reservation := range open {
+		if _, end := reservationSpan(reservation.Date, reservation.StartTime, reservation.EndTime); !end.After(now) {
+			ended[reservation.Status] = append(ended[reservation.Status], reservation.ID)
+		}
+	}
+
+	next := map[models.ReservationStatus]models.ReservationStatus{
+		models.ReservationConfirmed: models.ReservationNoShow,
+		models.ReservationCheckedIn: models.ReservationCompleted,
+	}
+	for status, ids := range ended {
+		if err := database.DB.Model(&models.Reservation{}).
+			Where("id IN ? AND status = ?", ids, status).
+			Update("status", next[status]).Error; err != nil {
+			log.Printf("Failed to close %s reservations: %v", status, err)
+		}
+	}
+}
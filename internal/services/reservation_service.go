@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"interview_Ping_20241219/internal/database"
 	"interview_Ping_20241219/internal/models"
 	"net/http"
@@ -8,6 +9,14 @@ import (
 	"time" // Add this import for string to int conversion
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReservationNotFound      = errors.New("reservation not found")
+	ErrReservationConflict      = errors.New("room is already reserved for this time period")
+	ErrRoomClosed               = errors.New("room is closed during this time period")
+	ErrRoomMaintenanceScheduled = errors.New("room is scheduled for maintenance during this time period")
 )
 
 type ReservationRequest struct {
@@ -23,7 +32,56 @@ func RegisterReservationRoutes(router *gin.Engine) {
 	{
 		reservations.GET("", ListReservations)
 		reservations.POST("", CreateReservation)
+		reservations.GET("/:id", GetReservation)
+		reservations.PATCH("/:id", UpdateReservation)
+		reservations.POST("/:id/cancel", CancelReservation)
+		reservations.POST("/:id/check-in", CheckInReservation)
+	}
+}
+
+// reservationSpan combines a reservation's date and times of day into the
+// UTC times it starts and ends
+func reservationSpan(date, startTime, endTime time.Time) (time.Time, time.Time) {
+	date, startTime, endTime = date.UTC(), startTime.UTC(), endTime.UTC()
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	start := day.Add(time.Duration(startTime.Hour())*time.Hour + time.Duration(startTime.Minute())*time.Minute)
+	end := day.Add(time.Duration(endTime.Hour())*time.Hour + time.Duration(endTime.Minute())*time.Minute)
+	return start, end
+}
+
+// checkReservationSlot checks that the room can be booked for the times,
+// ignoring the reservation being moved (0 when creating one). Times are
+// half-open, so back-to-back bookings do not conflict. A room put into
+// maintenance by hand is busy from now on, as in the availability calendar.
+// Run it in the transaction holding the room lock.
+func checkReservationSlot(tx *gorm.DB, room models.Room, excludeID uint, date, startTime, endTime, now time.Time) error {
+	var conflictCount int64
+	if err := tx.Model(&models.Reservation{}).
+		Where("room_id = ? AND date = ? AND start_time < ? AND end_time > ?", room.ID, date, endTime, startTime).
+		Where("status <> ? AND id <> ?", models.ReservationCancelled, excludeID).
+		Count(&conflictCount).Error; err != nil {
+		return err
+	}
+	if conflictCount > 0 {
+		return ErrReservationConflict
+	}
+
+	// Reservation times are in UTC, like the date they are on
+	start, end := reservationSpan(date, startTime, endTime)
+	if roomClosedDuring(room, start, end) {
+		return ErrRoomClosed
+	}
+	if room.Status == models.RoomStatusMaintenance && end.After(now) {
+		return ErrRoomMaintenanceScheduled
 	}
+	inMaintenance, err := maintenanceOverlaps(tx, room.ID, start, end)
+	if err != nil {
+		return err
+	}
+	if inMaintenance {
+		return ErrRoomMaintenanceScheduled
+	}
+	return nil
 }
 
 // ListReservations handles GET /reservations with optional query parameters
@@ -31,6 +89,7 @@ func ListReservations(c *gin.Context) {
 	// Get query parameters
 	roomID := c.Query("room_id")
 	date := c.Query("date")
+	status := c.Query("status")
 	limitStr := c.DefaultQuery("limit", "10")
 
 	// Convert limit to integer
@@ -53,6 +112,9 @@ func ListReservations(c *gin.Context) {
 	if date != "" {
 		query = query.Where("date = ?", date)
 	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
 
 	// Apply limit
 	query = query.Limit(limit)
@@ -122,34 +184,34 @@ func CreateReservation(c *gin.Context) {
 		return
 	}
 
-	// Check for conflicting reservations
-	var conflictCount int64
-	database.DB.Model(&models.Reservation{}).
-		Where("room_id = ? AND date = ? AND ((start_time <= ? AND end_time > ?) OR (start_time < ? AND end_time >= ?))",
-			req.RoomID, date, endTime, startTime, endTime, startTime).
-		Count(&conflictCount)
-
-	if conflictCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "Room is already reserved for this time period",
+	if !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "end_time must be after start_time",
 		})
 		return
 	}
 
-	// Create reservation
+	// Create the reservation under a lock on the room so two bookings of the
+	// same slot cannot both pass the checks
 	reservation := models.Reservation{
 		RoomID:    req.RoomID,
 		PlayerID:  req.PlayerID,
 		Date:      date,
 		StartTime: startTime,
 		EndTime:   endTime,
+		Status:    models.ReservationConfirmed,
 	}
-
-	if err := database.DB.Create(&reservation).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create reservation",
-			"details": err.Error(),
-		})
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if room, err = lockRoom(tx, req.RoomID); err != nil {
+			return err
+		}
+		if err := checkReservationSlot(tx, room, 0, date, startTime, endTime, time.Now()); err != nil {
+			return err
+		}
+		return tx.Create(&reservation).Error
+	})
+	if err != nil {
+		reservationError(c, err, room, "Failed to create reservation")
 		return
 	}
 
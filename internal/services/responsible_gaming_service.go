@@ -0,0 +1,586 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/engine"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	LIMIT_INCREASE_DELAY    = 24 * time.Hour
+	MAX_COOL_OFF_HOURS      = 24 * 42 // six weeks
+	MIN_SELF_EXCLUSION_DAYS = 180
+)
+
+func RegisterResponsibleGamingRoutes(router *gin.Engine) {
+	players := router.Group("/players")
+	{
+		players.GET("/:id/limits", GetPlayerLimits)
+		players.PUT("/:id/limits", SetPlayerLimit)
+		players.DELETE("/:id/limits/:type/:period", RemovePlayerLimit)
+		players.GET("/:id/limits/audit", GetResponsibleGamingAudit)
+		players.POST("/:id/cool-off", StartCoolOff)
+		players.POST("/:id/self-exclusion", StartSelfExclusion)
+	}
+}
+
+type PlayerLimitRequest struct {
+	Type   models.LimitType   `json:"type" binding:"required"`
+	Period models.LimitPeriod `json:"period" binding:"required"`
+	Amount float64            `json:"amount" binding:"required,gt=0"`
+}
+
+type CoolOffRequest struct {
+	Hours  int    `json:"hours" binding:"required,min=1"`
+	Reason string `json:"reason"`
+}
+
+type SelfExclusionRequest struct {
+	Days      int    `json:"days"`
+	Permanent bool   `json:"permanent"`
+	Reason    string `json:"reason"`
+}
+
+// GetPlayerLimits handles GET /players/:id/limits
+func GetPlayerLimits(c *gin.Context) {
+	player, ok := findPlayerOr404(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	limits, err := loadPlayerLimits(database.DB, player.ID, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch limits",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var restrictions []models.PlayerRestriction
+	if err := activeRestrictions(database.DB, player.ID, now).Find(&restrictions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch restrictions",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"limits":       limits,
+		"restrictions": restrictions,
+	})
+}
+
+// SetPlayerLimit handles PUT /players/:id/limits. Lowering a limit takes
+// effect immediately; raising it only after LIMIT_INCREASE_DELAY.
+func SetPlayerLimit(c *gin.Context) {
+	player, ok := findPlayerOr404(c)
+	if !ok {
+		return
+	}
+
+	var req PlayerLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !validLimitType(req.Type) || !validLimitPeriod(req.Period) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid limit type or period",
+		})
+		return
+	}
+
+	now := time.Now()
+	tx := database.DB.Begin()
+
+	if err := applyPendingLimitChanges(tx, player.ID, now); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to apply pending limit changes",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var limit models.PlayerLimit
+	err := tx.Where("player_id = ? AND type = ? AND period = ?", player.ID, req.Type, req.Period).
+		First(&limit).Error
+
+	amount := req.Amount
+	audit := models.ResponsibleGamingAudit{
+		PlayerID:  player.ID,
+		LimitType: req.Type,
+		Period:    req.Period,
+		NewValue:  &amount,
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		limit = models.PlayerLimit{
+			PlayerID: player.ID,
+			Type:     req.Type,
+			Period:   req.Period,
+			Amount:   amount,
+		}
+		audit.Action = "limit_set"
+		audit.EffectiveAt = &now
+	case err != nil:
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch limit",
+			"details": err.Error(),
+		})
+		return
+	case amount <= limit.Amount:
+		oldValue := limit.Amount
+		limit.Amount = amount
+		limit.PendingAmount = nil
+		limit.PendingRemoval = false
+		limit.PendingEffectiveAt = nil
+		audit.Action = "limit_decreased"
+		audit.OldValue = &oldValue
+		audit.EffectiveAt = &now
+	default:
+		oldValue := limit.Amount
+		effectiveAt := now.Add(LIMIT_INCREASE_DELAY)
+		limit.PendingAmount = &amount
+		limit.PendingRemoval = false
+		limit.PendingEffectiveAt = &effectiveAt
+		audit.Action = "limit_increase_requested"
+		audit.OldValue = &oldValue
+		audit.EffectiveAt = &effectiveAt
+	}
+
+	if err := tx.Save(&limit).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save limit",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := tx.Create(&audit).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to record limit change",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// RemovePlayerLimit handles DELETE /players/:id/limits/:type/:period.
+// Removing a limit counts as an increase and is delayed the same way.
+func RemovePlayerLimit(c *gin.Context) {
+	player, ok := findPlayerOr404(c)
+	if !ok {
+		return
+	}
+
+	var limit models.PlayerLimit
+	if err := database.DB.Where("player_id = ? AND type = ? AND period = ?",
+		player.ID, c.Param("type"), c.Param("period")).First(&limit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Limit not found",
+		})
+		return
+	}
+
+	oldValue := limit.Amount
+	effectiveAt := time.Now().Add(LIMIT_INCREASE_DELAY)
+	limit.PendingAmount = nil
+	limit.PendingRemoval = true
+	limit.PendingEffectiveAt = &effectiveAt
+
+	tx := database.DB.Begin()
+	if err := tx.Save(&limit).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save limit",
+			"details": err.Error(),
+		})
+		return
+	}
+	audit := models.ResponsibleGamingAudit{
+		PlayerID:    player.ID,
+		Action:      "limit_removal_requested",
+		LimitType:   limit.Type,
+		Period:      limit.Period,
+		OldValue:    &oldValue,
+		EffectiveAt: &effectiveAt,
+	}
+	if err := tx.Create(&audit).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to record limit change",
+			"details": err.Error(),
+		})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// StartCoolOff handles POST /players/:id/cool-off
+func StartCoolOff(c *gin.Context) {
+	var req CoolOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.Hours > MAX_COOL_OFF_HOURS {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Cool-off period cannot exceed %d hours, use self-exclusion instead", MAX_COOL_OFF_HOURS),
+		})
+		return
+	}
+
+	now := time.Now()
+	endsAt := now.Add(time.Duration(req.Hours) * time.Hour)
+	createRestriction(c, models.PlayerRestriction{
+		Type:     models.RestrictionCoolOff,
+		StartsAt: now,
+		EndsAt:   &endsAt,
+		Reason:   req.Reason,
+	})
+}
+
+// StartSelfExclusion handles POST /players/:id/self-exclusion
+func StartSelfExclusion(c *gin.Context) {
+	var req SelfExclusionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !req.Permanent && req.Days < MIN_SELF_EXCLUSION_DAYS {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Self-exclusion must last at least %d days or be permanent", MIN_SELF_EXCLUSION_DAYS),
+		})
+		return
+	}
+
+	now := time.Now()
+	restriction := models.PlayerRestriction{
+		Type:     models.RestrictionSelfExclusion,
+		StartsAt: now,
+		Reason:   req.Reason,
+	}
+	if !req.Permanent {
+		endsAt := now.AddDate(0, 0, req.Days)
+		restriction.EndsAt = &endsAt
+	}
+	createRestriction(c, restriction)
+}
+
+func createRestriction(c *gin.Context, restriction models.PlayerRestriction) {
+	player, ok := findPlayerOr404(c)
+	if !ok {
+		return
+	}
+	restriction.PlayerID = player.ID
+
+	tx := database.DB.Begin()
+	if err := tx.Create(&restriction).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create restriction",
+			"details": err.Error(),
+		})
+		return
+	}
+	audit := models.ResponsibleGamingAudit{
+		PlayerID:    player.ID,
+		Action:      fmt.Sprintf("%s_started", restriction.Type),
+		EffectiveAt: &restriction.StartsAt,
+		Details:     restriction.Reason,
+	}
+	if restriction.EndsAt != nil {
+		audit.Details = fmt.Sprintf("until %s %s", restriction.EndsAt.Format(time.RFC3339), restriction.Reason)
+	}
+	if err := tx.Create(&audit).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to record restriction",
+			"details": err.Error(),
+		})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, restriction)
+}
+
+// GetResponsibleGamingAudit handles GET /players/:id/limits/audit
+func GetResponsibleGamingAudit(c *gin.Context) {
+	player, ok := findPlayerOr404(c)
+	if !ok {
+		return
+	}
+
+	// Surface increases that became effective since the last request
+	if err := applyPendingLimitChanges(database.DB, player.ID, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to apply pending limit changes",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var audits []models.ResponsibleGamingAudit
+	if err := database.DB.Where("player_id = ?", player.ID).
+		Order("created_at DESC, id DESC").
+		Find(&audits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch audit log",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, audits)
+}
+
+// limitViolation describes why a responsible gaming check blocked an action
+type limitViolation struct {
+	Message string
+	Limit   *models.PlayerLimit
+	Used    float64
+	Until   *time.Time
+}
+
+func (v *limitViolation) response() gin.H {
+	body := gin.H{"error": v.Message}
+	if v.Limit != nil {
+		body["limit_type"] = v.Limit.Type
+		body["period"] = v.Limit.Period
+		body["limit"] = v.Limit.Amount
+		body["used"] = v.Used
+	}
+	if v.Until != nil {
+		body["until"] = v.Until
+	}
+	return body
+}
+
+// checkDepositLimits is applied by ProcessPayment and paid challenge entries
+// before charging the player
+func checkDepositLimits(playerID uint, amount float64, now time.Time) (*limitViolation, error) {
+	if violation, err := checkRestrictions(playerID, now); violation != nil || err != nil {
+		return violation, err
+	}
+
+	limits, err := loadPlayerLimits(database.DB, playerID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range limits {
+		limit := &limits[i]
+		if limit.Type != models.LimitTypeDeposit {
+			continue
+		}
+
+		var deposited float64
+		if err := database.DB.Model(&models.Payment{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("player_id = ? AND status = ? AND created_at >= ?",
+				playerID, models.PaymentStatusSuccess, limitPeriodStart(limit.Period, now)).
+			Scan(&deposited).Error; err != nil {
+			return nil, err
+		}
+
+		if deposited+amount > limit.Amount {
+			return &limitViolation{Message: "Deposit limit exceeded", Limit: limit, Used: engine.RoundToCents(deposited)}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// checkChallengeLimits is applied by JoinChallenge before taking the entry fee
+func checkChallengeLimits(playerID uint, now time.Time) (*limitViolation, error) {
+	if violation, err := checkRestrictions(playerID, now); violation != nil || err != nil {
+		return violation, err
+	}
+
+	limits, err := loadPlayerLimits(database.DB, playerID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range limits {
+		limit := &limits[i]
+		if limit.Type != models.LimitTypeLoss && limit.Type != models.LimitTypeEntryCount {
+			continue
+		}
+
+		var totals struct {
+			Entries int64
+			Won     float64
+		}
+		if err := database.DB.Model(&models.Challenge{}).
+			Select("COUNT(*) AS entries, COALESCE(SUM(CASE WHEN is_winner THEN amount + jackpot_amount ELSE 0 END), 0) AS won").
+			Where("player_id = ? AND voided = ? AND created_at >= ?", playerID, false, limitPeriodStart(limit.Period, now)).
+			Scan(&totals).Error; err != nil {
+			return nil, err
+		}
+
+		switch limit.Type {
+		case models.LimitTypeEntryCount:
+			if float64(totals.Entries+1) > limit.Amount {
+				return &limitViolation{Message: "Challenge entry limit reached", Limit: limit, Used: float64(totals.Entries)}, nil
+			}
+		case models.LimitTypeLoss:
+			loss := float64(totals.Entries)*CHALLENGE_COST - totals.Won
+			if loss+CHALLENGE_COST > limit.Amount {
+				return &limitViolation{Message: "Loss limit reached", Limit: limit, Used: engine.RoundToCents(loss)}, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func checkRestrictions(playerID uint, now time.Time) (*limitViolation, error) {
+	var restriction models.PlayerRestriction
+	err := activeRestrictions(database.DB, playerID, now).First(&restriction).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	message := "Player is in a cool-off period"
+	if restriction.Type == models.RestrictionSelfExclusion {
+		message = "Player is self-excluded"
+	}
+	return &limitViolation{Message: message, Until: restriction.EndsAt}, nil
+}
+
+// activeRestrictions lists restrictions in force at now, permanent ones first
+func activeRestrictions(db *gorm.DB, playerID uint, now time.Time) *gorm.DB {
+	return db.Where("player_id = ? AND starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", playerID, now, now).
+		Order("ends_at DESC NULLS FIRST")
+}
+
+// loadPlayerLimits returns the player's limits after promoting any pending
+// increases or removals whose delay has passed
+func loadPlayerLimits(db *gorm.DB, playerID uint, now time.Time) ([]models.PlayerLimit, error) {
+	if err := applyPendingLimitChanges(db, playerID, now); err != nil {
+		return nil, err
+	}
+
+	var limits []models.PlayerLimit
+	if err := db.Where("player_id = ?", playerID).Order("type, period").Find(&limits).Error; err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// applyPendingLimitChanges applies the player's pending changes that are due.
+// The due rows are locked, so concurrent requests apply and audit each change
+// once: the one that waited finds the change already applied.
+func applyPendingLimitChanges(db *gorm.DB, playerID uint, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var due []models.PlayerLimit
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("player_id = ? AND pending_effective_at <= ?", playerID, now).
+			Find(&due).Error; err != nil {
+			return err
+		}
+
+		for _, limit := range due {
+			oldValue := limit.Amount
+			audit := models.ResponsibleGamingAudit{
+				PlayerID:    playerID,
+				LimitType:   limit.Type,
+				Period:      limit.Period,
+				OldValue:    &oldValue,
+				EffectiveAt: limit.PendingEffectiveAt,
+			}
+
+			if limit.PendingRemoval {
+				audit.Action = "limit_removed"
+				if err := tx.Delete(&limit).Error; err != nil {
+					return err
+				}
+			} else {
+				audit.Action = "limit_increase_applied"
+				audit.NewValue = limit.PendingAmount
+				limit.Amount = *limit.PendingAmount
+				limit.PendingAmount = nil
+				limit.PendingEffectiveAt = nil
+				if err := tx.Save(&limit).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Create(&audit).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// limitPeriodStart returns the start of the calendar day, week (Monday) or month containing now
+func limitPeriodStart(period models.LimitPeriod, now time.Time) time.Time {
+	if period == models.LimitPeriodMonth {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	start, _ := periodStart(string(period), now)
+	return start
+}
+
+func validLimitType(limitType models.LimitType) bool {
+	switch limitType {
+	case models.LimitTypeDeposit, models.LimitTypeLoss, models.LimitTypeEntryCount:
+		return true
+	}
+	return false
+}
+
+func validLimitPeriod(period models.LimitPeriod) bool {
+	switch period {
+	case models.LimitPeriodDay, models.LimitPeriodWeek, models.LimitPeriodMonth:
+		return true
+	}
+	return false
+}
+
+func findPlayerOr404(c *gin.Context) (models.Player, bool) {
+	var player models.Player
+	if err := database.DB.First(&player, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Player not found",
+		})
+		return player, false
+	}
+	return player, true
+}
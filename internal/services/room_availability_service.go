@@ -0,0 +1,338 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	ROOM_OPENS_AT  = "00:00"
+	ROOM_CLOSES_AT = "24:00"
+
+	AVAILABILITY_DEFAULT_SLOT = 30 * time.Minute
+	AVAILABILITY_MIN_SLOT     = 5 * time.Minute
+	AVAILABILITY_MAX_DAYS     = 31
+	AVAILABILITY_MAX_SLOTS    = 5000
+)
+
+type SlotReason string
+
+// Reasons a slot is busy, from the highest priority
+const (
+	SlotReasonClosed      SlotReason = "closed"
+	SlotReasonMaintenance SlotReason = "maintenance"
+	SlotReasonReserved    SlotReason = "reserved"
+)
+
+var slotReasonPriority = map[SlotReason]int{
+	SlotReasonClosed:      3,
+	SlotReasonMaintenance: 2,
+	SlotReasonReserved:    1,
+}
+
+type AvailabilitySlot struct {
+	Start  time.Time  `json:"start"`
+	End    time.Time  `json:"end"`
+	Free   bool       `json:"free"`
+	Reason SlotReason `json:"reason,omitempty"`
+}
+
+type busyInterval struct {
+	Start  time.Time
+	End    time.Time
+	Reason SlotReason
+}
+
+func (b busyInterval) overlaps(start, end time.Time) bool {
+	return b.Start.Before(end) && b.End.After(start)
+}
+
+// parseClock parses an HH:mm time of day, allowing 24:00 for the end of the day
+func parseClock(s string) (time.Duration, error) {
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q. Use HH:mm", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// validateOpeningHours checks that a room opens before it closes on the same day
+func validateOpeningHours(opensAt, closesAt string) error {
+	opens, err := parseClock(opensAt)
+	if err != nil {
+		return err
+	}
+	closes, err := parseClock(closesAt)
+	if err != nil {
+		return err
+	}
+	if closes <= opens {
+		return errors.New("closes_at must be after opens_at")
+	}
+	return nil
+}
+
+// closedIntervals lists the times outside the room's opening hours on every
+// UTC day touching [from, to)
+func closedIntervals(room models.Room, from, to time.Time) []busyInterval {
+	opens, err := parseClock(room.OpensAt)
+	if err != nil {
+		opens = 0
+	}
+	closes, err := parseClock(room.ClosesAt)
+	if err != nil {
+		closes = 24 * time.Hour
+	}
+
+	var closed []busyInterval
+	for day := from.UTC().Truncate(24 * time.Hour); day.Before(to); day = day.Add(24 * time.Hour) {
+		if opens > 0 {
+			closed = append(closed, busyInterval{day, day.Add(opens), SlotReasonClosed})
+		}
+		if closes < 24*time.Hour {
+			closed = append(closed, busyInterval{day.Add(closes), day.Add(24 * time.Hour), SlotReasonClosed})
+		}
+	}
+	return closed
+}
+
+// roomClosedDuring reports whether [start, end) falls outside the room's
+// opening hours
+func roomClosedDuring(room models.Room, start, end time.Time) bool {
+	for _, closed := range closedIntervals(room, start, end) {
+		if closed.overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+// roomBusyIntervals collects what keeps each room from being booked in
+// [from, to): opening hours, maintenance windows, reservations, and a room
+// put into maintenance by hand, which is busy from now on
+func roomBusyIntervals(db *gorm.DB, rooms []models.Room, from, to, now time.Time) (map[uint][]busyInterval, error) {
+	busy := make(map[uint][]busyInterval, len(rooms))
+	if len(rooms) == 0 {
+		return busy, nil
+	}
+	ids := make([]uint, len(rooms))
+	for i, room := range rooms {
+		ids[i] = room.ID
+		busy[room.ID] = closedIntervals(room, from, to)
+		if room.Status == models.RoomStatusMaintenance && now.Before(to) {
+			busy[room.ID] = append(busy[room.ID], busyInterval{now, to, SlotReasonMaintenance})
+		}
+	}
+
+	var windows []models.MaintenanceWindow
+	if err := db.Where("room_id IN ? AND cancelled_at IS NULL AND starts_at < ? AND ends_at > ?", ids, to, from).
+		Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	for _, window := range windows {
+		busy[window.RoomID] = append(busy[window.RoomID], busyInterval{window.StartsAt, window.EndsAt, SlotReasonMaintenance})
+	}
+
+	// Reservations never cross midnight, so the days of the range cover them
+	var reservations []models.Reservation
+	if err := db.Where("room_id IN ? AND date >= ? AND date < ? AND status <> ?",
+		ids, from.UTC().Truncate(24*time.Hour), to, models.ReservationCancelled).
+		Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+	for _, reservation := range reservations {
+		start, end := reservationSpan(reservation.Date, reservation.StartTime, reservation.EndTime)
+		if start.Before(to) && end.After(from) {
+			busy[reservation.RoomID] = append(busy[reservation.RoomID], busyInterval{start, end, SlotReasonReserved})
+		}
+	}
+
+	return busy, nil
+}
+
+// availabilitySlots splits [from, to) into slots and marks each with the
+// highest priority reason it is busy, if any
+func availabilitySlots(from, to time.Time, slot time.Duration, busy []busyInterval) []AvailabilitySlot {
+	var slots []AvailabilitySlot
+	for start := from; start.Before(to); start = start.Add(slot) {
+		end := start.Add(slot)
+		if end.After(to) {
+			end = to
+		}
+		s := AvailabilitySlot{Start: start, End: end, Free: true}
+		for _, b := range busy {
+			if b.overlaps(start, end) && slotReasonPriority[b.Reason] > slotReasonPriority[s.Reason] {
+				s.Free = false
+				s.Reason = b.Reason
+			}
+		}
+		slots = append(slots, s)
+	}
+	return slots
+}
+
+// parseAvailabilityTime accepts RFC 3339 or a YYYY-MM-DD date, which means
+// midnight UTC
+func parseAvailabilityTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// parseAvailabilityRange reads from and to, defaulting to the current UTC day
+// when defaults is set
+func parseAvailabilityRange(c *gin.Context, now time.Time, defaults bool) (time.Time, time.Time, error) {
+	fromParam, toParam := c.Query("from"), c.Query("to")
+	if !defaults && (fromParam == "" || toParam == "") {
+		return time.Time{}, time.Time{}, errors.New("from and to are required")
+	}
+
+	from := now.UTC().Truncate(24 * time.Hour)
+	if fromParam != "" {
+		var err error
+		if from, err = parseAvailabilityTime(fromParam); err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from. Use RFC 3339 or YYYY-MM-DD")
+		}
+	}
+	to := from.Add(24 * time.Hour)
+	if toParam != "" {
+		var err error
+		if to, err = parseAvailabilityTime(toParam); err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to. Use RFC 3339 or YYYY-MM-DD")
+		}
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, errors.New("to must be after from")
+	}
+	if to.Sub(from) > AVAILABILITY_MAX_DAYS*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("the range can span at most %d days", AVAILABILITY_MAX_DAYS)
+	}
+	return from, to, nil
+}
+
+// GetRoomAvailability handles GET /rooms/:id/availability?from=&to=&slot=
+func GetRoomAvailability(c *gin.Context) {
+	room, ok := loadRoom(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	from, to, err := parseAvailabilityRange(c, now, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	slot := AVAILABILITY_DEFAULT_SLOT
+	if slotParam := c.Query("slot"); slotParam != "" {
+		if slot, err = time.ParseDuration(slotParam); err != nil || slot < AVAILABILITY_MIN_SLOT {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid slot parameter. Use a duration of at least %s, e.g. 30m", AVAILABILITY_MIN_SLOT),
+			})
+			return
+		}
+	}
+	if to.Sub(from)/slot > AVAILABILITY_MAX_SLOTS {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Too many slots. Use a larger slot or a shorter range (at most %d slots)", AVAILABILITY_MAX_SLOTS),
+		})
+		return
+	}
+
+	busy, err := roomBusyIntervals(database.DB, []models.Room{room}, from, to, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to compute availability",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"room_id":   room.ID,
+		"from":      from,
+		"to":        to,
+		"slot":      slot.String(),
+		"opens_at":  room.OpensAt,
+		"closes_at": room.ClosesAt,
+		"slots":     availabilitySlots(from, to, slot, busy[room.ID]),
+	})
+}
+
+// FindAvailableRooms handles GET /rooms/availability?from=&to=&min_capacity=,
+// listing the rooms free for the whole span
+func FindAvailableRooms(c *gin.Context) {
+	now := time.Now()
+	from, to, err := parseAvailabilityRange(c, now, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	query := database.DB.Model(&models.Room{})
+	if minCapacity := c.Query("min_capacity"); minCapacity != "" {
+		capacity, err := strconv.Atoi(minCapacity)
+		if err != nil || capacity < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid min_capacity parameter",
+			})
+			return
+		}
+		query = query.Where("capacity >= ?", capacity)
+	}
+
+	var rooms []models.Room
+	if err := query.Order("id").Find(&rooms).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch rooms",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	busy, err := roomBusyIntervals(database.DB, rooms, from, to, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to compute availability",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	available := make([]models.Room, 0, len(rooms))
+	for _, room := range rooms {
+		free := true
+		for _, b := range busy[room.ID] {
+			if b.overlaps(from, to) {
+				free = false
+				break
+			}
+		}
+		if free {
+			available = append(available, room)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":  from,
+		"to":    to,
+		"rooms": available,
+	})
+}
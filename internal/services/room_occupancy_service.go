@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const DEFAULT_ROOM_CAPACITY = 4
+
+var (
+	ErrRoomNotFound         = errors.New("room not found")
+	ErrRoomFull             = errors.New("room is full")
+	ErrRoomOccupied         = errors.New("room has occupants")
+	ErrRoomUnderMaintenance = errors.New("room is under maintenance")
+	ErrAlreadyInRoom        = errors.New("player is already in a room")
+	ErrNotInRoom            = errors.New("player is not in this room")
+)
+
+type RoomOccupancyRequest struct {
+	PlayerID uint `json:"player_id" binding:"required"`
+}
+
+// occupancyStatus is the status a room should have for its occupancy.
+// Rooms under maintenance keep their status.
+func occupancyStatus(room models.Room) models.RoomStatus {
+	if room.Status == models.RoomStatusMaintenance {
+		return room.Status
+	}
+	if room.Occupancy > 0 {
+		return models.RoomStatusOccupied
+	}
+	return models.RoomStatusAvailable
+}
+
+// joinRoom adds the player to the room's occupants, updates its occupancy
+// and status and logs the entry, all under a lock on the room row
+func joinRoom(roomID, playerID uint, now time.Time) (models.Room, models.RoomOccupant, error) {
+	var room models.Room
+	var occupant models.RoomOccupant
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if room, err = lockRoom(tx, roomID); err != nil {
+			return err
+		}
+		if room.Status == models.RoomStatusMaintenance {
+			return ErrRoomUnderMaintenance
+		}
+		due, err := maintenanceDue(tx, room.ID, now)
+		if err != nil {
+			return err
+		}
+		if due {
+			return ErrRoomUnderMaintenance
+		}
+		if room.Occupancy >= room.Capacity {
+			return ErrRoomFull
+		}
+
+		occupant = models.RoomOccupant{RoomID: room.ID, PlayerID: playerID, JoinedAt: now}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&occupant)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyInRoom
+		}
+
+		room.Occupancy++
+		if err := tx.Model(&room).Select("occupancy", "updated_at").Updates(&room).Error; err != nil {
+			return err
+		}
+		if err := setRoomStatus(tx, &room, occupancyStatus(room), models.RoomStatusSourceOccupancy, "", nil); err != nil {
+			return err
+		}
+
+		_, err = gamelog.Write(tx, playerID, models.ActionEnterRoom, models.LogDetails{"room_id": room.ID})
+		return err
+	})
+
+	return room, occupant, err
+}
+
+// leaveRoom removes the player from the room's occupants, the reverse of
+// joinRoom
+func leaveRoom(roomID, playerID uint) (models.Room, error) {
+	var room models.Room
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if room, err = lockRoom(tx, roomID); err != nil {
+			return err
+		}
+
+		result := tx.Where("room_id = ? AND player_id = ?", room.ID, playerID).Delete(&models.RoomOccupant{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotInRoom
+		}
+
+		room.Occupancy--
+		if err := tx.Model(&room).Select("occupancy", "updated_at").Updates(&room).Error; err != nil {
+			return err
+		}
+		if err := setRoomStatus(tx, &room, occupancyStatus(room), models.RoomStatusSourceOccupancy, "", nil); err != nil {
+			return err
+		}
+
+		_, err = gamelog.Write(tx, playerID, models.ActionLeaveRoom, models.LogDetails{"room_id": room.ID})
+		return err
+	})
+
+	return room, err
+}
+
+// loadRoom fetches the room named by the :id path parameter, responding with
+// 404 if it does not exist
+func loadRoom(c *gin.Context) (models.Room, bool) {
+	var room models.Room
+	if err := database.DB.First(&room, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Room not found",
+		})
+		return room, false
+	}
+	return room, true
+}
+
+func roomOccupancyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrRoomNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+	case errors.Is(err, ErrRoomFull), errors.Is(err, ErrRoomUnderMaintenance),
+		errors.Is(err, ErrAlreadyInRoom), errors.Is(err, ErrNotInRoom):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update room occupancy",
+			"details": err.Error(),
+		})
+	}
+}
+
+// JoinRoom handles POST /rooms/:id/join
+func JoinRoom(c *gin.Context) {
+	current, ok := loadRoom(c)
+	if !ok {
+		return
+	}
+
+	var req RoomOccupancyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var player models.Player
+	if err := database.DB.First(&player, req.PlayerID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Player not found",
+		})
+		return
+	}
+
+	room, occupant, err := joinRoom(current.ID, req.PlayerID, time.Now())
+	if err != nil {
+		roomOccupancyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"room":     room,
+		"occupant": occupant,
+	})
+}
+
+// LeaveRoom handles POST /rooms/:id/leave
+func LeaveRoom(c *gin.Context) {
+	current, ok := loadRoom(c)
+	if !ok {
+		return
+	}
+
+	var req RoomOccupancyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	room, err := leaveRoom(current.ID, req.PlayerID)
+	if err != nil {
+		roomOccupancyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"room": room,
+	})
+}
+
+// GetRoomOccupants handles GET /rooms/:id/occupants
+func GetRoomOccupants(c *gin.Context) {
+	room, ok := loadRoom(c)
+	if !ok {
+		return
+	}
+
+	var occupants []models.RoomOccupant
+	if err := database.DB.Preload("Player").
+		Where("room_id = ?", room.ID).
+		Order("joined_at, id").
+		Find(&occupants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch occupants",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"room_id":   room.ID,
+		"capacity":  room.Capacity,
+		"occupancy": room.Occupancy,
+		"occupants": occupants,
+	})
+}
@@ -1,16 +1,40 @@
 package services
 
 import (
+	"errors"
 	"interview_Ping_20241219/internal/database"
 	"interview_Ping_20241219/internal/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RoomRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+	Capacity    *int   `json:"capacity" binding:"omitempty,min=1"`
+	OpensAt     string `json:"opens_at"`  // HH:mm UTC, default 00:00
+	ClosesAt    string `json:"closes_at"` // HH:mm UTC, default 24:00
+}
+
+// openingHours fills in the default opening hours and validates them
+func (req *RoomRequest) openingHours(room models.Room) (string, string, error) {
+	opensAt, closesAt := room.OpensAt, room.ClosesAt
+	if opensAt == "" {
+		opensAt = ROOM_OPENS_AT
+	}
+	if closesAt == "" {
+		closesAt = ROOM_CLOSES_AT
+	}
+	if req.OpensAt != "" {
+		opensAt = req.OpensAt
+	}
+	if req.ClosesAt != "" {
+		closesAt = req.ClosesAt
+	}
+	return opensAt, closesAt, validateOpeningHours(opensAt, closesAt)
 }
 
 func RegisterRoomRoutes(router *gin.Engine) {
@@ -18,9 +42,19 @@ func RegisterRoomRoutes(router *gin.Engine) {
 	{
 		rooms.GET("", ListRooms)
 		rooms.POST("", CreateRoom)
+		rooms.GET("/availability", FindAvailableRooms)
 		rooms.GET("/:id", GetRoom)
 		rooms.PUT("/:id", UpdateRoom)
 		rooms.DELETE("/:id", DeleteRoom)
+		rooms.POST("/:id/join", JoinRoom)
+		rooms.POST("/:id/leave", LeaveRoom)
+		rooms.GET("/:id/occupants", GetRoomOccupants)
+		rooms.GET("/:id/availability", GetRoomAvailability)
+		rooms.POST("/:id/status", RequireAdmin(), UpdateRoomStatus)
+		rooms.GET("/:id/status/history", GetRoomStatusHistory)
+		rooms.GET("/:id/maintenance", ListMaintenanceWindows)
+		rooms.POST("/:id/maintenance", RequireAdmin(), CreateMaintenanceWindow)
+		rooms.DELETE("/:id/maintenance/:window_id", RequireAdmin(), CancelMaintenanceWindow)
 	}
 }
 
@@ -78,7 +112,19 @@ func CreateRoom(c *gin.Context) {
 	room := models.Room{
 		Name:        req.Name,
 		Description: req.Description,
-		Status:      "available",
+		Status:      models.RoomStatusAvailable,
+		Capacity:    DEFAULT_ROOM_CAPACITY,
+	}
+	if req.Capacity != nil {
+		room.Capacity = *req.Capacity
+	}
+	var err error
+	if room.OpensAt, room.ClosesAt, err = req.openingHours(room); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid opening hours",
+			"details": err.Error(),
+		})
+		return
 	}
 
 	if err := database.DB.Create(&room).Error; err != nil {
@@ -148,10 +194,38 @@ func UpdateRoom(c *gin.Context) {
 		return
 	}
 
-	room.Name = req.Name
-	room.Description = req.Description
+	if _, _, err := req.openingHours(room); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid opening hours",
+			"details": err.Error(),
+		})
+		return
+	}
 
-	if err := database.DB.Save(&room).Error; err != nil {
+	// Lock the room so the capacity check sees the current occupancy, and only
+	// write the edited columns so concurrent joins and leaves are kept
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, room.ID).Error; err != nil {
+			return err
+		}
+		room.Name = req.Name
+		room.Description = req.Description
+		if req.Capacity != nil {
+			if *req.Capacity < room.Occupancy {
+				return ErrRoomFull
+			}
+			room.Capacity = *req.Capacity
+		}
+		room.OpensAt, room.ClosesAt, _ = req.openingHours(room)
+		return tx.Model(&room).Select("name", "description", "capacity", "opens_at", "closes_at", "updated_at").Updates(&room).Error
+	})
+	if errors.Is(err, ErrRoomFull) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Capacity cannot be below the current occupancy",
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to update room",
 			"details": err.Error(),
@@ -174,7 +248,32 @@ func UpdateRoom(c *gin.Context) {
 // @Router /rooms/{id} [delete]
 func DeleteRoom(c *gin.Context) {
 	id := c.Param("id")
-	if err := database.DB.Delete(&models.Room{}, id).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var room models.Room
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if room.Occupancy > 0 {
+			return ErrRoomOccupied
+		}
+		if err := tx.Where("room_id = ?", room.ID).Delete(&models.MaintenanceWindow{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("room_id = ?", room.ID).Delete(&models.RoomStatusChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&room).Error
+	})
+	if errors.Is(err, ErrRoomOccupied) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Room has occupants",
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to delete room",
 			"details": err.Error(),
@@ -0,0 +1,493 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidRoomTransition     = errors.New("invalid room status transition")
+	ErrMaintenanceInProgress     = errors.New("a maintenance window is in progress")
+	ErrMaintenanceOverlap        = errors.New("overlaps another maintenance window")
+	ErrMaintenanceReserved       = errors.New("overlaps reservations that have not been cancelled")
+	ErrMaintenanceWindowNotFound = errors.New("maintenance window not found")
+	ErrMaintenanceWindowClosed   = errors.New("maintenance window has already ended or been cancelled")
+)
+
+type RoomStatusRequest struct {
+	Status models.RoomStatus `json:"status" binding:"required"`
+	Reason string            `json:"reason"`
+}
+
+type MaintenanceWindowRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason"`
+}
+
+// setRoomStatus moves the locked room to status to and records the change.
+// Setting the current status again is a no-op.
+func setRoomStatus(tx *gorm.DB, room *models.Room, to models.RoomStatus, source models.RoomStatusSource, reason string, windowID *uint) error {
+	if room.Status == to {
+		return nil
+	}
+	if !room.Status.CanTransitionTo(to) {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidRoomTransition, room.Status, to)
+	}
+
+	change := models.RoomStatusChange{
+		RoomID:              room.ID,
+		FromStatus:          room.Status,
+		ToStatus:            to,
+		Source:              source,
+		Reason:              reason,
+		MaintenanceWindowID: windowID,
+	}
+	room.Status = to
+	if err := tx.Model(room).Select("status", "updated_at").Updates(room).Error; err != nil {
+		return err
+	}
+	return tx.Create(&change).Error
+}
+
+func lockRoom(tx *gorm.DB, roomID uint) (models.Room, error) {
+	var room models.Room
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return room, ErrRoomNotFound
+		}
+		return room, err
+	}
+	return room, nil
+}
+
+// maintenanceInProgress reports whether a window has put the room into
+// maintenance and not ended yet
+func maintenanceInProgress(tx *gorm.DB, roomID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.MaintenanceWindow{}).
+		Where("room_id = ? AND started_at IS NOT NULL AND ended_at IS NULL AND cancelled_at IS NULL", roomID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// maintenanceDue reports whether a maintenance window covers now, whether or
+// not it has started yet. Rooms are kept from filling up while a window waits
+// for them to empty.
+func maintenanceDue(tx *gorm.DB, roomID uint, now time.Time) (bool, error) {
+	var count int64
+	err := tx.Model(&models.MaintenanceWindow{}).
+		Where("room_id = ? AND ended_at IS NULL AND cancelled_at IS NULL AND starts_at <= ? AND ends_at > ?", roomID, now, now).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// maintenanceOverlaps reports whether a scheduled maintenance window of the
+// room overlaps [start, end)
+func maintenanceOverlaps(tx *gorm.DB, roomID uint, start, end time.Time) (bool, error) {
+	var count int64
+	err := tx.Model(&models.MaintenanceWindow{}).
+		Where("room_id = ? AND cancelled_at IS NULL AND starts_at < ? AND ends_at > ?", roomID, end, start).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// reservationsOverlap reports whether a confirmed or checked-in reservation of
+// the room overlaps [start, end)
+func reservationsOverlap(tx *gorm.DB, roomID uint, start, end time.Time) (bool, error) {
+	// Reservations never cross midnight, so the days of the range cover them
+	var reservations []models.Reservation
+	if err := tx.Where("room_id = ? AND date >= ? AND date < ? AND status IN ?",
+		roomID, start.UTC().Truncate(24*time.Hour), end,
+		[]models.ReservationStatus{models.ReservationConfirmed, models.ReservationCheckedIn}).
+		Find(&reservations).Error; err != nil {
+		return false, err
+	}
+	for _, reservation := range reservations {
+		resStart, resEnd := reservationSpan(reservation.Date, reservation.StartTime, reservation.EndTime)
+		if resStart.Before(end) && resEnd.After(start) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// changeRoomStatus applies a manual status change. Occupied follows the room's
+// occupants and cannot be set by hand, so neither can anything else while
+// players are in the room, and a room stays in maintenance until its
+// maintenance window ends or is cancelled.
+func changeRoomStatus(roomID uint, to models.RoomStatus, reason string) (models.Room, error) {
+	var room models.Room
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if room, err = lockRoom(tx, roomID); err != nil {
+			return err
+		}
+		if room.Occupancy > 0 {
+			return ErrRoomOccupied
+		}
+		if room.Status == models.RoomStatusMaintenance && to != models.RoomStatusMaintenance {
+			inProgress, err := maintenanceInProgress(tx, room.ID)
+			if err != nil {
+				return err
+			}
+			if inProgress {
+				return ErrMaintenanceInProgress
+			}
+		}
+		return setRoomStatus(tx, &room, to, models.RoomStatusSourceManual, reason, nil)
+	})
+
+	return room, err
+}
+
+// startMaintenanceWindow puts the room into maintenance when its window
+// starts. An occupied room is left alone and tried again on the next run,
+// since joining is blocked for the window it empties out.
+func startMaintenanceWindow(windowID uint, now time.Time) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var window models.MaintenanceWindow
+		if err := tx.First(&window, windowID).Error; err != nil {
+			return err
+		}
+		room, err := lockRoom(tx, window.RoomID)
+		if err != nil {
+			return err
+		}
+		// Reload under the room lock, which every window change holds
+		if err := tx.First(&window, windowID).Error; err != nil {
+			return err
+		}
+		if window.StartedAt != nil || window.EndedAt != nil || window.CancelledAt != nil || window.EndsAt.Before(now) {
+			return nil
+		}
+		if room.Status == models.RoomStatusOccupied {
+			return nil
+		}
+
+		if err := setRoomStatus(tx, &room, models.RoomStatusMaintenance, models.RoomStatusSourceMaintenance, window.Reason, &window.ID); err != nil {
+			return err
+		}
+		window.StartedAt = &now
+		return tx.Model(&window).Select("started_at", "updated_at").Updates(&window).Error
+	})
+}
+
+// closeMaintenanceWindow ends or cancels the window of the locked room. The
+// room becomes available again if this window put it into maintenance; a
+// room that was already in maintenance by hand stays that way.
+func closeMaintenanceWindow(tx *gorm.DB, room *models.Room, window *models.MaintenanceWindow, now time.Time, cancel bool) error {
+	if window.EndedAt != nil || window.CancelledAt != nil {
+		return ErrMaintenanceWindowClosed
+	}
+	if cancel {
+		window.CancelledAt = &now
+	}
+	if window.StartedAt != nil || !cancel {
+		window.EndedAt = &now
+	}
+	if err := tx.Model(window).Select("ended_at", "cancelled_at", "updated_at").Updates(window).Error; err != nil {
+		return err
+	}
+	if window.StartedAt == nil || room.Status != models.RoomStatusMaintenance {
+		return nil
+	}
+
+	var started int64
+	if err := tx.Model(&models.RoomStatusChange{}).
+		Where("maintenance_window_id = ? AND to_status = ?", window.ID, models.RoomStatusMaintenance).
+		Count(&started).Error; err != nil {
+		return err
+	}
+	if started == 0 {
+		return nil
+	}
+	reason := "maintenance window ended"
+	if cancel {
+		reason = "maintenance window cancelled"
+	}
+	return setRoomStatus(tx, room, models.RoomStatusAvailable, models.RoomStatusSourceMaintenance, reason, &window.ID)
+}
+
+func endMaintenanceWindow(windowID uint, now time.Time) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var window models.MaintenanceWindow
+		if err := tx.First(&window, windowID).Error; err != nil {
+			return err
+		}
+		room, err := lockRoom(tx, window.RoomID)
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&window, windowID).Error; err != nil {
+			return err
+		}
+		if window.EndedAt != nil || window.CancelledAt != nil {
+			return nil
+		}
+		return closeMaintenanceWindow(tx, &room, &window, now, false)
+	})
+}
+
+// StartRoomMaintenanceScheduler moves rooms in and out of maintenance as their
+// maintenance windows start and end. Each window is changed under a lock on
+// its room, so running it on several app instances is safe.
+func StartRoomMaintenanceScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			runMaintenanceWindows(time.Now())
+		}
+	}()
+}
+
+func runMaintenanceWindows(now time.Time) {
+	var ending []models.MaintenanceWindow
+	if err := database.DB.Where("ended_at IS NULL AND cancelled_at IS NULL AND ends_at <= ?", now).
+		Find(&ending).Error; err != nil {
+		log.Printf("Failed to fetch ending maintenance windows: %v", err)
+		return
+	}
+	for _, window := range ending {
+		if err := endMaintenanceWindow(window.ID, now); err != nil {
+			log.Printf("Failed to end maintenance window %d: %v", window.ID, err)
+		}
+	}
+
+	var starting []models.MaintenanceWindow
+	if err := database.DB.Where("started_at IS NULL AND ended_at IS NULL AND cancelled_at IS NULL AND starts_at <= ? AND ends_at > ?", now, now).
+		Find(&starting).Error; err != nil {
+		log.Printf("Failed to fetch starting maintenance windows: %v", err)
+		return
+	}
+	for _, window := range starting {
+		if err := startMaintenanceWindow(window.ID, now); err != nil {
+			log.Printf("Failed to start maintenance window %d: %v", window.ID, err)
+		}
+	}
+}
+
+func roomStatusError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrRoomNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+	case errors.Is(err, ErrMaintenanceWindowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Maintenance window not found"})
+	case errors.Is(err, ErrInvalidRoomTransition), errors.Is(err, ErrMaintenanceInProgress),
+		errors.Is(err, ErrMaintenanceOverlap), errors.Is(err, ErrMaintenanceWindowClosed),
+		errors.Is(err, ErrMaintenanceReserved), errors.Is(err, ErrRoomOccupied):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update room status",
+			"details": err.Error(),
+		})
+	}
+}
+
+// UpdateRoomStatus handles POST /rooms/:id/status
+func UpdateRoomStatus(c *gin.Context) {
+	current, ok := loadRoom(c)
+	if !ok {
+		return
+	}
+
+	var req RoomStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !req.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status. Use available or maintenance",
+		})
+		return
+	}
+	if req.Status == models.RoomStatusOccupied {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A room is occupied while players are in it and cannot be set occupied directly",
+		})
+		return
+	}
+
+	room, err := changeRoomStatus(current.ID, req.Status, req.Reason)
+	if err != nil {
+		roomStatusError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, room)
+}
+
+// GetRoomStatusHistory handles GET /rooms/:id/status/history
+func GetRoomStatusHistory(c *gin.Context) {
+	room, ok := loadRoom(c)
+	if !ok {
+		return
+	}
+
+	var changes []models.RoomStatusChange
+	if err := database.DB.Where("room_id = ?", room.ID).
+		Order("created_at DESC, id DESC").
+		Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch status history",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// ListMaintenanceWindows handles GET /rooms/:id/maintenance. Ended and
+// cancelled windows are only listed with ?all=true.
+func ListMaintenanceWindows(c *gin.Context) {
+	room, ok := loadRoom(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Where("room_id = ?", room.ID)
+	if c.Query("all") != "true" {
+		query = query.Where("ended_at IS NULL AND cancelled_at IS NULL")
+	}
+
+	var windows []models.MaintenanceWindow
+	if err := query.Order("starts_at, id").Find(&windows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch maintenance windows",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, windows)
+}
+
+// CreateMaintenanceWindow handles POST /rooms/:id/maintenance. A window that
+// has already started puts the room into maintenance right away. Windows
+// cannot overlap reservations, which have to be moved or cancelled first.
+func CreateMaintenanceWindow(c *gin.Context) {
+	room, ok := loadRoom(c)
+	if !ok {
+		return
+	}
+
+	var req MaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+	now := time.Now()
+	if !req.EndsAt.After(req.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ends_at must be after starts_at",
+		})
+		return
+	}
+	if !req.EndsAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ends_at must be in the future",
+		})
+		return
+	}
+
+	window := models.MaintenanceWindow{
+		RoomID:   room.ID,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Reason:   req.Reason,
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockRoom(tx, room.ID); err != nil {
+			return err
+		}
+		var overlapping int64
+		if err := tx.Model(&models.MaintenanceWindow{}).
+			Where("room_id = ? AND ended_at IS NULL AND cancelled_at IS NULL AND starts_at < ? AND ends_at > ?", room.ID, req.EndsAt, req.StartsAt).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return ErrMaintenanceOverlap
+		}
+		reserved, err := reservationsOverlap(tx, room.ID, req.StartsAt, req.EndsAt)
+		if err != nil {
+			return err
+		}
+		if reserved {
+			return ErrMaintenanceReserved
+		}
+		return tx.Create(&window).Error
+	})
+	if err != nil {
+		roomStatusError(c, err)
+		return
+	}
+
+	if !window.StartsAt.After(now) {
+		if err := startMaintenanceWindow(window.ID, now); err != nil {
+			roomStatusError(c, err)
+			return
+		}
+		database.DB.First(&window, window.ID)
+	}
+
+	c.JSON(http.StatusCreated, window)
+}
+
+// CancelMaintenanceWindow handles DELETE /rooms/:id/maintenance/:window_id.
+// Cancelling a window in progress ends the maintenance now.
+func CancelMaintenanceWindow(c *gin.Context) {
+	current, ok := loadRoom(c)
+	if !ok {
+		return
+	}
+	windowID, err := strconv.ParseUint(c.Param("window_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid maintenance window ID",
+		})
+		return
+	}
+
+	var window models.MaintenanceWindow
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		room, err := lockRoom(tx, current.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("room_id = ?", room.ID).First(&window, windowID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMaintenanceWindowNotFound
+			}
+			return err
+		}
+		return closeMaintenanceWindow(tx, &room, &window, time.Now(), true)
+	})
+	if err != nil {
+		roomStatusError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
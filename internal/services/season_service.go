@@ -0,0 +1,304 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/engine"
+	"interview_Ping_20241219/internal/models"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrSeasonNotEnded = errors.New("season has not ended yet")
+
+type SeasonRequest struct {
+	Name        string    `json:"name" binding:"required"`
+	StartTime   time.Time `json:"start_time" binding:"required"`
+	EndTime     time.Time `json:"end_time" binding:"required"`
+	PrizePool   float64   `json:"prize_pool" binding:"gte=0"`
+	TopN        int       `json:"top_n" binding:"required,min=1"`
+	EntryPoints *int      `json:"entry_points"`
+	WinPoints   *int      `json:"win_points"`
+}
+
+func RegisterSeasonRoutes(router *gin.Engine) {
+	seasons := router.Group("/seasons")
+	{
+		seasons.GET("", ListSeasons)
+		seasons.POST("", RequireAdmin(), CreateSeason)
+		seasons.GET("/:id", GetSeason)
+		seasons.GET("/:id/leaderboard", GetSeasonLeaderboard)
+		seasons.POST("/:id/finalize", RequireAdmin(), FinalizeSeason)
+	}
+}
+
+// ListSeasons handles GET /seasons
+func ListSeasons(c *gin.Context) {
+	var seasons []models.Season
+	if err := database.DB.Order("start_time DESC").Find(&seasons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch seasons",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, seasons)
+}
+
+// CreateSeason handles POST /seasons
+func CreateSeason(c *gin.Context) {
+	var req SeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !req.EndTime.After(req.StartTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "end_time must be after start_time",
+		})
+		return
+	}
+
+	season := models.Season{
+		Name:        req.Name,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		PrizePool:   req.PrizePool,
+		TopN:        req.TopN,
+		EntryPoints: 1,
+		WinPoints:   100,
+		Status:      models.SeasonStatusActive,
+	}
+	if req.EntryPoints != nil {
+		season.EntryPoints = *req.EntryPoints
+	}
+	if req.WinPoints != nil {
+		season.WinPoints = *req.WinPoints
+	}
+
+	if err := database.DB.Create(&season).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create season",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, season)
+}
+
+// GetSeason handles GET /seasons/:id
+func GetSeason(c *gin.Context) {
+	var season models.Season
+	if err := database.DB.First(&season, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Season not found",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, season)
+}
+
+// GetSeasonLeaderboard handles GET /seasons/:id/leaderboard. Finalized
+// seasons return the archived standings; others are computed live.
+func GetSeasonLeaderboard(c *gin.Context) {
+	var season models.Season
+	if err := database.DB.First(&season, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Season not found",
+		})
+		return
+	}
+
+	var standings []models.SeasonStanding
+	var err error
+	if season.Status == models.SeasonStatusFinalized {
+		err = database.DB.Where("season_id = ?", season.ID).Order("rank").Find(&standings).Error
+	} else {
+		standings, err = computeSeasonStandings(database.DB, season)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch season leaderboard",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"season":    season,
+		"standings": standings,
+	})
+}
+
+// FinalizeSeason handles POST /seasons/:id/finalize, running the end-of-season
+// job for one season without waiting for the scheduler
+func FinalizeSeason(c *gin.Context) {
+	var season models.Season
+	if err := database.DB.First(&season, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Season not found",
+		})
+		return
+	}
+
+	standings, err := finalizeSeason(season.ID, time.Now())
+	if errors.Is(err, ErrSeasonNotEnded) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to finalize season",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"season_id": season.ID,
+		"standings": standings,
+	})
+}
+
+// StartSeasonScheduler finalizes seasons once they end. Finalization locks the
+// season row, so running it on several app instances is safe.
+func StartSeasonScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			finalizeEndedSeasons(time.Now())
+		}
+	}()
+}
+
+func finalizeEndedSeasons(now time.Time) {
+	var ended []models.Season
+	if err := database.DB.Where("status = ? AND end_time <= ?", models.SeasonStatusActive, now).
+		Find(&ended).Error; err != nil {
+		log.Printf("Failed to fetch ended seasons: %v", err)
+		return
+	}
+
+	for _, season := range ended {
+		if _, err := finalizeSeason(season.ID, now); err != nil {
+			log.Printf("Failed to finalize season %d: %v", season.ID, err)
+		}
+	}
+}
+
+// finalizeSeason archives the final standings and credits prizes to the top
+// TopN players' wallets in one transaction. Finalizing twice is a no-op that
+// returns the archived standings.
+func finalizeSeason(seasonID uint, now time.Time) ([]models.SeasonStanding, error) {
+	var standings []models.SeasonStanding
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var season models.Season
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&season, seasonID).Error; err != nil {
+			return err
+		}
+		if season.Status == models.SeasonStatusFinalized {
+			return tx.Where("season_id = ?", season.ID).Order("rank").Find(&standings).Error
+		}
+		if season.EndTime.After(now) {
+			return ErrSeasonNotEnded
+		}
+
+		var err error
+		standings, err = computeSeasonStandings(tx, season)
+		if err != nil {
+			return err
+		}
+
+		prizes := seasonPrizes(season.PrizePool, season.TopN, len(standings))
+		for i := range standings {
+			if i < len(prizes) {
+				standings[i].Prize = prizes[i]
+			}
+		}
+
+		if len(standings) > 0 {
+			if err := tx.Create(&standings).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, standing := range standings {
+			if standing.Prize <= 0 {
+				continue
+			}
+			if _, err := creditWallet(tx, standing.PlayerID, standing.Prize, models.WalletSeasonPrize,
+				fmt.Sprintf("season:%d", season.ID)); err != nil {
+				return err
+			}
+		}
+
+		season.Status = models.SeasonStatusFinalized
+		season.FinalizedAt = &now
+		return tx.Save(&season).Error
+	})
+
+	return standings, err
+}
+
+// computeSeasonStandings ranks players by points, then wins, from the
+// season's non-voided challenges
+func computeSeasonStandings(db *gorm.DB, season models.Season) ([]models.SeasonStanding, error) {
+	var standings []models.SeasonStanding
+	err := db.Model(&models.Challenge{}).
+		Select("challenges.player_id, players.name AS player_name, "+
+			"COUNT(*) AS entries, "+
+			"SUM(CASE WHEN challenges.is_winner THEN 1 ELSE 0 END) AS wins, "+
+			"COUNT(*) * ? + SUM(CASE WHEN challenges.is_winner THEN 1 ELSE 0 END) * ? AS points",
+			season.EntryPoints, season.WinPoints).
+		Joins("JOIN players ON players.id = challenges.player_id").
+		Where("challenges.created_at >= ? AND challenges.created_at < ? AND challenges.voided = ?",
+			season.StartTime, season.EndTime, false).
+		Group("challenges.player_id, players.name").
+		Order("points DESC, wins DESC, challenges.player_id").
+		Scan(&standings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range standings {
+		standings[i].SeasonID = season.ID
+		standings[i].Rank = i + 1
+	}
+	return standings, nil
+}
+
+// seasonPrizes splits the prize pool across the top N places weighted by
+// rank, so with N=3 the shares are 3/6, 2/6 and 1/6. Rounding leftovers go
+// to first place so the full pool is paid out.
+func seasonPrizes(prizePool float64, topN, players int) []float64 {
+	if players < topN {
+		topN = players
+	}
+	if topN <= 0 || prizePool <= 0 {
+		return nil
+	}
+
+	totalWeight := float64(topN*(topN+1)) / 2
+	prizes := make([]float64, topN)
+	paid := 0.0
+	for i := range prizes {
+		prizes[i] = engine.RoundToCents(prizePool * float64(topN-i) / totalWeight)
+		paid += prizes[i]
+	}
+	prizes[0] = engine.RoundToCents(prizes[0] + prizePool - paid)
+	return prizes
+}
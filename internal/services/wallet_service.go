@@ -0,0 +1,71 @@
+package services
+
+import (
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/engine"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func RegisterWalletRoutes(router *gin.Engine) {
+	players := router.Group("/players")
+	{
+		players.GET("/:id/wallet", GetWallet)
+	}
+}
+
+// GetWallet handles GET /players/:id/wallet and returns the balance with the latest transactions
+func GetWallet(c *gin.Context) {
+	player, ok := findPlayerOr404(c)
+	if !ok {
+		return
+	}
+
+	var transactions []models.WalletTransaction
+	if err := database.DB.Where("player_id = ?", player.ID).
+		Order("created_at DESC, id DESC").
+		Limit(50).
+		Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch wallet transactions",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"player_id":    player.ID,
+		"balance":      player.Balance,
+		"transactions": transactions,
+	})
+}
+
+// creditWallet adds amount (negative to debit) to the player's balance and
+// records it in the ledger. It must run inside the caller's transaction.
+func creditWallet(tx *gorm.DB, playerID uint, amount float64, transactionType models.WalletTransactionType, reference string) (models.WalletTransaction, error) {
+	var player models.Player
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&player, playerID).Error; err != nil {
+		return models.WalletTransaction{}, err
+	}
+
+	player.Balance = engine.RoundToCents(player.Balance + amount)
+	if err := tx.Model(&player).Update("balance", player.Balance).Error; err != nil {
+		return models.WalletTransaction{}, err
+	}
+
+	transaction := models.WalletTransaction{
+		PlayerID:  playerID,
+		Type:      transactionType,
+		Amount:    amount,
+		Balance:   player.Balance,
+		Reference: reference,
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		return models.WalletTransaction{}, err
+	}
+	return transaction, nil
+}
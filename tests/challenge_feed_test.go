@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamChallenges(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestChallenge(t)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/challenges/stream")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		t.Fatalf("StreamChallenges() content type = %v, want text/event-stream", contentType)
+	}
+
+	events := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "event:") {
+				events <- strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			}
+		}
+		close(events)
+	}()
+
+	waitFor := func(want string) {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case got, ok := <-events:
+				if !ok {
+					t.Fatalf("Stream closed before %q event", want)
+				}
+				if got == want {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("Timed out waiting for %q event", want)
+			}
+		}
+	}
+
+	// Initial pool snapshot
+	waitFor("pool")
+
+	payloadBytes, _ := json.Marshal(map[string]interface{}{
+		"player_id": playerID,
+		"amount":    20.01,
+	})
+	joinResp, err := http.Post(server.URL+"/challenges", "application/json", bytes.NewReader(payloadBytes))
+	if err != nil {
+		t.Fatalf("Failed to join challenge: %v", err)
+	}
+	joinResp.Body.Close()
+	if joinResp.StatusCode != http.StatusCreated {
+		t.Fatalf("JoinChallenge() status = %v, want %v", joinResp.StatusCode, http.StatusCreated)
+	}
+
+	waitFor("entry")
+	waitFor("pool")
+}
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"interview_Ping_20241219/internal/database"
 	"interview_Ping_20241219/internal/models"
+	"interview_Ping_20241219/internal/services"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -41,6 +43,14 @@ func TestJoinChallenge(t *testing.T) {
 			},
 			wantStatus: http.StatusCreated,
 		},
+		{
+			name: "Within Cooldown",
+			payload: map[string]interface{}{
+				"player_id": playerID,
+				"amount":    20.01,
+			},
+			wantStatus: http.StatusTooEarly,
+		},
 		{
 			name: "Invalid Amount",
 			payload: map[string]interface{}{
@@ -90,3 +100,234 @@ func TestGetChallengeResults(t *testing.T) {
 		t.Error("Response missing pool_amount field")
 	}
 }
+
+func TestGetHouseRevenue(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestChallenge(t)
+
+	// Two entries' rake, the seed after a win and a void
+	challenge := setupTestVoidChallenge(t, playerID, true)
+	revenues := []models.HouseRevenue{
+		{ChallengeID: challenge.ID, Type: models.HouseRevenueRake, Amount: 1},
+		{ChallengeID: challenge.ID, Type: models.HouseRevenueRake, Amount: 1},
+		{ChallengeID: challenge.ID, Type: models.HouseRevenuePoolSeed, Amount: -100},
+		{ChallengeID: challenge.ID, Type: models.HouseRevenueVoid, Amount: -1.5},
+	}
+	if err := database.DB.Create(&revenues).Error; err != nil {
+		t.Fatalf("Failed to create house revenue: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		token      string
+		wantStatus int
+	}{
+		{
+			name:       "All Days",
+			query:      "",
+			token:      "test-admin-token",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Date Range",
+			query: fmt.Sprintf("?start_date=%s&end_date=%s",
+				time.Now().AddDate(0, 0, -7).Format("2006-01-02"),
+				time.Now().Format("2006-01-02")),
+			token:      "test-admin-token",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid Date",
+			query:      "?start_date=yesterday",
+			token:      "test-admin-token",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Missing Admin Token",
+			query:      "",
+			token:      "",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/challenges/revenue"+tt.query, nil)
+			if tt.token != "" {
+				req.Header.Set("X-Admin-Token", tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("GetHouseRevenue() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			if w.Code == http.StatusOK {
+				var response struct {
+					Days  []services.DailyHouseRevenue `json:"days"`
+					Total float64                      `json:"total"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+				if len(response.Days) != 1 {
+					t.Fatalf("GetHouseRevenue() returned %d days, want 1", len(response.Days))
+				}
+				day := response.Days[0]
+				if day.Rake != 2 || day.PoolSeed != -100 || day.Net != -99.5 {
+					t.Errorf("GetHouseRevenue() day = %+v, want rake 2, pool seed -100, net -99.5", day)
+				}
+				if response.Total != -99.5 {
+					t.Errorf("GetHouseRevenue() total = %v, want -99.5", response.Total)
+				}
+			}
+		})
+	}
+}
+
+func TestPlayerChallengeHistoryAndStats(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestChallenge(t)
+
+	base := time.Now().Add(-time.Hour)
+	outcomes := []bool{false, false, true, false, false, false}
+	for i, isWinner := range outcomes {
+		challenge := models.Challenge{PlayerID: playerID, Amount: 20.01, IsWinner: isWinner}
+		if isWinner {
+			challenge.Amount = 150
+		}
+		challenge.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		database.DB.Create(&challenge)
+	}
+
+	t.Run("History Pagination", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/players/%d/challenges?page=2&page_size=4", playerID), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetPlayerChallenges() status = %v, want %v", w.Code, http.StatusOK)
+		}
+
+		var response struct {
+			Challenges []models.Challenge `json:"challenges"`
+			Total      int64              `json:"total"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.Total != 6 || len(response.Challenges) != 2 {
+			t.Errorf("GetPlayerChallenges() total = %v, page size = %v, want 6 and 2",
+				response.Total, len(response.Challenges))
+		}
+	})
+
+	t.Run("History Time Filter", func(t *testing.T) {
+		start := url.QueryEscape(base.Add(150 * time.Second).Format(time.RFC3339))
+		req := httptest.NewRequest("GET", fmt.Sprintf("/players/%d/challenges?start_time=%s", playerID, start), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Total int64 `json:"total"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code != http.StatusOK || response.Total != 3 {
+			t.Errorf("GetPlayerChallenges() status = %v, total = %v, want %v and 3", w.Code, response.Total, http.StatusOK)
+		}
+
+		for _, query := range []string{"start_time=yesterday", "end_time=2024-01-02"} {
+			req := httptest.NewRequest("GET", fmt.Sprintf("/players/%d/challenges?%s", playerID, query), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("GetPlayerChallenges() with %s status = %v, want %v", query, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("History Unknown Player", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/players/999999/challenges", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GetPlayerChallenges() status = %v, want %v", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/players/%d/challenge-stats", playerID), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetPlayerChallengeStats() status = %v, want %v", w.Code, http.StatusOK)
+		}
+
+		var stats map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if stats["total_entries"] != float64(6) {
+			t.Errorf("total_entries = %v, want 6", stats["total_entries"])
+		}
+		if stats["win_count"] != float64(1) {
+			t.Errorf("win_count = %v, want 1", stats["win_count"])
+		}
+		if stats["longest_losing_streak"] != float64(3) {
+			t.Errorf("longest_losing_streak = %v, want 3", stats["longest_losing_streak"])
+		}
+		if stats["last_win_date"] == nil {
+			t.Error("last_win_date is missing")
+		}
+	})
+}
+
+func TestJoinChallengeWithPayment(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestChallenge(t)
+
+	w := sendJSON(router, "POST", "/challenges", map[string]interface{}{
+		"player_id":      playerID,
+		"amount":         20.01,
+		"payment_method": "invalid_method",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("JoinChallenge() with invalid method status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+
+	w = sendJSON(router, "POST", "/challenges", map[string]interface{}{
+		"player_id":      playerID,
+		"amount":         20.01,
+		"payment_method": "third_party",
+	})
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	// The mock processors decline a share of payments at random
+	switch w.Code {
+	case http.StatusCreated:
+		var payment models.Payment
+		if err := database.DB.First(&payment, response["payment_id"]).Error; err != nil {
+			t.Fatalf("Payment %v not found: %v", response["payment_id"], err)
+		}
+		if payment.Status != models.PaymentStatusSuccess || payment.Amount != 20.01 {
+			t.Errorf("Payment = %v %v, want success 20.01", payment.Status, payment.Amount)
+		}
+	case http.StatusPaymentRequired:
+		var count int64
+		database.DB.Model(&models.Challenge{}).Where("player_id = ?", playerID).Count(&count)
+		if count != 0 {
+			t.Errorf("Declined payment created %d challenges, want 0", count)
+		}
+	default:
+		t.Errorf("JoinChallenge() status = %v, want %v or %v, response = %v",
+			w.Code, http.StatusCreated, http.StatusPaymentRequired, w.Body.String())
+	}
+}
@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func setupTestVoidChallenge(t *testing.T, playerID uint, isWinner bool) models.Challenge {
+	challenge := models.Challenge{
+		PlayerID:            playerID,
+		Amount:              20.01,
+		Rake:                1,
+		JackpotContribution: 0.4,
+		IsWinner:            isWinner,
+	}
+	if err := database.DB.Create(&challenge).Error; err != nil {
+		t.Fatalf("Failed to create test challenge: %v", err)
+	}
+	database.DB.Model(&models.ChallengePool{}).Where("1 = 1").
+		Updates(map[string]interface{}{"amount": 500, "mega_jackpot": 50})
+	return challenge
+}
+
+func sendAdminJSON(method, path string, payload interface{}, token string) *http.Request {
+	payloadBytes, _ := json.Marshal(payload)
+	req := httptest.NewRequest(method, path, bytes.NewReader(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Admin-Token", token)
+	}
+	return req
+}
+
+func TestVoidChallenge(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestChallenge(t)
+	challenge := setupTestVoidChallenge(t, playerID, false)
+
+	tests := []struct {
+		name        string
+		challengeID uint
+		token       string
+		wantStatus  int
+	}{
+		{
+			name:        "Missing Admin Token",
+			challengeID: challenge.ID,
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "Void Challenge",
+			challengeID: challenge.ID,
+			token:       "test-admin-token",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "Already Voided",
+			challengeID: challenge.ID,
+			token:       "test-admin-token",
+			wantStatus:  http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := sendAdminJSON("POST", fmt.Sprintf("/challenges/%d/void", tt.challengeID),
+				map[string]interface{}{"reason": "RNG incident"}, tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("VoidChallenge() status = %v, want %v, response = %v", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	var player models.Player
+	database.DB.First(&player, playerID)
+	if player.Balance != 20.01 {
+		t.Errorf("Player balance = %v, want the entry fee of 20.01 refunded", player.Balance)
+	}
+
+	var pool models.ChallengePool
+	database.DB.First(&pool)
+	if pool.Amount != 481.39 || pool.MegaJackpot != 49.6 {
+		t.Errorf("Pool = %v / %v, want contribution reversed to 481.39 / 49.6", pool.Amount, pool.MegaJackpot)
+	}
+
+	var logCount int64
+	database.DB.Model(&models.GameLog{}).Where("player_id = ?", playerID).Count(&logCount)
+	if logCount != 1 {
+		t.Errorf("Void wrote %d game logs, want 1", logCount)
+	}
+}
+
+func TestVoidChallengesByTimeRange(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestChallenge(t)
+	setupTestVoidChallenge(t, playerID, false)
+	setupTestVoidChallenge(t, playerID, false)
+	setupTestVoidChallenge(t, playerID, true)
+
+	req := sendAdminJSON("POST", "/challenges/void", map[string]interface{}{
+		"start_time": time.Now().Add(-time.Hour).Format(time.RFC3339),
+		"end_time":   time.Now().Add(time.Hour).Format(time.RFC3339),
+		"reason":     "RNG incident",
+	}, "test-admin-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("VoidChallenges() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["voided"] != float64(3) {
+		t.Errorf("VoidChallenges() voided = %v, want 3", response["voided"])
+	}
+}
+
+func TestVoidWinningChallenge(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestChallenge(t)
+	winner := setupTestVoidChallenge(t, playerID, true)
+	database.DB.Model(&winner).Updates(map[string]interface{}{
+		"amount":            100,
+		"is_jackpot_winner": true,
+		"jackpot_amount":    30,
+	})
+
+	req := sendAdminJSON("POST", fmt.Sprintf("/challenges/%d/void", winner.ID),
+		map[string]interface{}{"reason": "RNG incident"}, "test-admin-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("VoidChallenge() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	// The refund is credited and the payout and jackpot taken back, leaving
+	// the balance negative
+	var player models.Player
+	database.DB.First(&player, playerID)
+	if player.Balance != -109.99 {
+		t.Errorf("Player balance = %v, want -109.99", player.Balance)
+	}
+
+	var pool models.ChallengePool
+	database.DB.First(&pool)
+	if pool.Amount != 581.39 || pool.MegaJackpot != 79.6 {
+		t.Errorf("Pool = %v / %v, want payout restored to 581.39 / 79.6", pool.Amount, pool.MegaJackpot)
+	}
+
+	var reversal models.WalletTransaction
+	if err := database.DB.Where("player_id = ? AND type = ?", playerID, models.WalletChallengeWinReversal).
+		First(&reversal).Error; err != nil {
+		t.Fatalf("Failed to find win reversal: %v", err)
+	}
+	if reversal.Amount != -130 {
+		t.Errorf("Win reversal amount = %v, want -130", reversal.Amount)
+	}
+}
+
+func TestVoidPaidChallengeWhenProcessorCannotRefund(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestChallenge(t)
+
+	// No processor exists for this method, so a processor refund would fail
+	payment := models.Payment{
+		PlayerID:      playerID,
+		Amount:        20.01,
+		Method:        models.PaymentMethod("retired_method"),
+		Status:        models.PaymentStatusSuccess,
+		TransactionID: "RM_test",
+	}
+	if err := database.DB.Create(&payment).Error; err != nil {
+		t.Fatalf("Failed to create test payment: %v", err)
+	}
+	challenge := setupTestVoidChallenge(t, playerID, false)
+	database.DB.Model(&challenge).Update("payment_id", payment.ID)
+
+	req := sendAdminJSON("POST", fmt.Sprintf("/challenges/%d/void", challenge.ID),
+		map[string]interface{}{"reason": "RNG incident"}, "test-admin-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("VoidChallenge() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	// The fee is refunded to the balance with the void, whatever the processor
+	var player models.Player
+	database.DB.First(&player, playerID)
+	if player.Balance != 20.01 {
+		t.Errorf("Player balance = %v, want 20.01", player.Balance)
+	}
+	var refunds int64
+	database.DB.Model(&models.WalletTransaction{}).
+		Where("player_id = ? AND type = ? AND reference = ?", playerID, models.WalletChallengeRefund, fmt.Sprintf("challenge:%d", challenge.ID)).
+		Count(&refunds)
+	if refunds != 1 {
+		t.Errorf("Refund transactions = %d, want 1", refunds)
+	}
+	database.DB.First(&payment, payment.ID)
+	if payment.Status != models.PaymentStatusSuccess {
+		t.Errorf("Payment status = %v, want it left %v", payment.Status, models.PaymentStatusSuccess)
+	}
+}
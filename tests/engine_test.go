@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"interview_Ping_20241219/internal/engine"
+	"testing"
+)
+
+// sequenceRNG returns the given values in order
+type sequenceRNG struct {
+	values []float64
+}
+
+func (r *sequenceRNG) Float64() float64 {
+	value := r.values[0]
+	r.values = r.values[1:]
+	return value
+}
+
+func TestResolveChallenge(t *testing.T) {
+	economics := engine.Economics{
+		Fee:                    20,
+		WinProbability:         0.01,
+		RakePercent:            5,
+		JackpotPercent:         2,
+		MegaJackpotProbability: 0.1,
+		MinPoolSeed:            100,
+	}
+
+	tests := []struct {
+		name        string
+		draws       []float64
+		wantWinner  bool
+		wantJackpot bool
+		wantPayout  float64
+		wantPool    engine.PoolState
+	}{
+		{
+			name:     "Loss Feeds Pool And Jackpot",
+			draws:    []float64{0.5},
+			wantPool: engine.PoolState{Amount: 1018.6, MegaJackpot: 50.4},
+		},
+		{
+			name:       "Win Reseeds Pool And Keeps Jackpot",
+			draws:      []float64{0.001, 0.5},
+			wantWinner: true,
+			wantPayout: 1018.6,
+			wantPool:   engine.PoolState{Amount: 100, MegaJackpot: 50.4},
+		},
+		{
+			name:        "Jackpot Win Empties Jackpot",
+			draws:       []float64{0.001, 0.01},
+			wantWinner:  true,
+			wantJackpot: true,
+			wantPayout:  1018.6,
+			wantPool:    engine.PoolState{Amount: 100, MegaJackpot: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := engine.PoolState{Amount: 1000, MegaJackpot: 50}
+			outcome := engine.Resolve(pool, economics, &sequenceRNG{values: tt.draws})
+
+			if outcome.Rake != 1 || outcome.JackpotContribution != 0.4 {
+				t.Errorf("Resolve() rake = %v, jackpot contribution = %v, want 1 and 0.4",
+					outcome.Rake, outcome.JackpotContribution)
+			}
+			if outcome.IsWinner != tt.wantWinner || outcome.IsJackpotWinner != tt.wantJackpot {
+				t.Errorf("Resolve() winner = %v, jackpot winner = %v, want %v and %v",
+					outcome.IsWinner, outcome.IsJackpotWinner, tt.wantWinner, tt.wantJackpot)
+			}
+			if outcome.Payout != tt.wantPayout {
+				t.Errorf("Resolve() payout = %v, want %v", outcome.Payout, tt.wantPayout)
+			}
+			if outcome.Pool != tt.wantPool {
+				t.Errorf("Resolve() pool = %+v, want %+v", outcome.Pool, tt.wantPool)
+			}
+		})
+	}
+}
+
+func TestEconomicsValidate(t *testing.T) {
+	valid := engine.Economics{
+		Fee:                    20.01,
+		WinProbability:         0.01,
+		RakePercent:            5,
+		JackpotPercent:         2,
+		MegaJackpotProbability: 0.1,
+		MinPoolSeed:            100,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(e *engine.Economics)
+		wantErr bool
+	}{
+		{"Valid", func(e *engine.Economics) {}, false},
+		{"Whole Fee Split", func(e *engine.Economics) { e.RakePercent, e.JackpotPercent = 60, 40 }, false},
+		{"No Seed", func(e *engine.Economics) { e.MinPoolSeed = 0 }, false},
+		{"Split Over 100 Percent", func(e *engine.Economics) { e.RakePercent, e.JackpotPercent = 90, 20 }, true},
+		{"Negative Rake", func(e *engine.Economics) { e.RakePercent = -5 }, true},
+		{"Negative Jackpot Share", func(e *engine.Economics) { e.JackpotPercent = -1 }, true},
+		{"Win Probability Above 1", func(e *engine.Economics) { e.WinProbability = 1.5 }, true},
+		{"Negative Mega-Jackpot Probability", func(e *engine.Economics) { e.MegaJackpotProbability = -0.1 }, true},
+		{"Negative Pool Seed", func(e *engine.Economics) { e.MinPoolSeed = -100 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			economics := valid
+			tt.modify(&economics)
+			err := economics.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"interview_Ping_20241219/internal/events"
+	"testing"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	broker := events.NewBroker()
+	sub := broker.Subscribe(4)
+	defer broker.Unsubscribe(sub)
+
+	broker.Publish("pool", map[string]float64{"pool_amount": 20.01})
+
+	event := <-sub.C
+	if event.Type != "pool" {
+		t.Errorf("Publish() event type = %v, want pool", event.Type)
+	}
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	broker := events.NewBroker()
+	slow := broker.Subscribe(1)
+	fast := broker.Subscribe(8)
+	defer broker.Unsubscribe(fast)
+
+	for i := 0; i < 3; i++ {
+		broker.Publish("entry", i)
+		<-fast.C
+	}
+
+	if broker.SubscriberCount() != 1 {
+		t.Errorf("SubscriberCount() = %v, want 1 after dropping the slow subscriber", broker.SubscriberCount())
+	}
+
+	// The buffered event is still delivered before the channel reports closed
+	<-slow.C
+	if _, ok := <-slow.C; ok {
+		t.Error("Slow subscriber channel is still open")
+	}
+
+	// Unsubscribing a dropped subscriber is a no-op
+	broker.Unsubscribe(slow)
+}
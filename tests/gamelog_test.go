@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"encoding/json"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"testing"
+	"time"
+)
+
+func TestValidateLogDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  models.LogActionType
+		details models.LogDetails
+		wantErr bool
+	}{
+		{
+			name:    "Enter Room",
+			action:  models.ActionEnterRoom,
+			details: models.LogDetails{"room_id": float64(5)},
+		},
+		{
+			name:    "Message Allowed On Any Action",
+			action:  models.ActionLogin,
+			details: models.LogDetails{"message": "hello"},
+		},
+		{
+			name:    "Missing Required Field",
+			action:  models.ActionEnterRoom,
+			details: models.LogDetails{},
+			wantErr: true,
+		},
+		{
+			name:    "Fractional Integer",
+			action:  models.ActionLeaveRoom,
+			details: models.LogDetails{"room_id": 1.5},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Field",
+			action:  models.ActionLogout,
+			details: models.LogDetails{"room_id": float64(5)},
+			wantErr: true,
+		},
+		{
+			name:    "Challenge Result",
+			action:  models.ActionChallengeEnd,
+			details: models.LogDetails{"challenge_id": uint(1), "amount": 20.01, "is_winner": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := gamelog.ValidateDetails(tt.action, tt.details)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDetails() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLogDetailsAcceptsLegacyText(t *testing.T) {
+	var entry models.GameLog
+	if err := json.Unmarshal([]byte(`{"details": "Player logged in"}`), &entry); err != nil {
+		t.Fatalf("Failed to parse log: %v", err)
+	}
+	if entry.Details["message"] != "Player logged in" {
+		t.Errorf("Details = %v, want message kept", entry.Details)
+	}
+}
+
+func TestLogHashSurvivesDatabaseRoundTrip(t *testing.T) {
+	seq := uint64(7)
+	written := models.GameLog{
+		PlayerID:  1,
+		Action:    models.ActionEnterRoom,
+		Details:   models.LogDetails{"room_id": uint(5)},
+		Seq:       &seq,
+		PrevHash:  "abc",
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.UTC),
+	}
+
+	// Postgres returns JSON numbers as float64 and timestamps in microseconds
+	read := written
+	read.Details = models.LogDetails{"room_id": float64(5)}
+	read.CreatedAt = written.CreatedAt.Truncate(time.Microsecond).In(time.FixedZone("UTC+8", 8*3600))
+
+	if gamelog.Hash(written) != gamelog.Hash(read) {
+		t.Error("Hash() differs after a database round trip")
+	}
+
+	read.Details = models.LogDetails{"room_id": float64(6)}
+	if gamelog.Hash(written) == gamelog.Hash(read) {
+		t.Error("Hash() did not change with the details")
+	}
+}
+
+func TestLogHashCoversLegacyActionName(t *testing.T) {
+	seq := uint64(1)
+	migrated := models.GameLog{
+		PlayerID:  1,
+		Action:    models.ActionLogin,
+		Seq:       &seq,
+		CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+	}
+
+	// Rows written before action codes held the Chinese value
+	legacy := migrated
+	legacy.Action = "登入"
+	if gamelog.Hash(migrated) != gamelog.Hash(legacy) {
+		t.Error("Hash() changed when a legacy action was renamed to its code")
+	}
+
+	other := migrated
+	other.Action = models.ActionLogout
+	if gamelog.Hash(migrated) == gamelog.Hash(other) {
+		t.Error("Hash() did not change with the action")
+	}
+}
+
+func TestLogActionDisplayName(t *testing.T) {
+	login, ok := gamelog.LookupAction(models.NormalizeLogAction("登入"))
+	if !ok {
+		t.Fatal("LookupAction() did not find the legacy login action")
+	}
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "zh-TW", want: "登入"},
+		{locale: "zh-HK", want: "登入"},
+		{locale: "en-GB", want: "Log in"},
+		{locale: "fr", want: "Log in"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := gamelog.DisplayName(login, tt.locale); got != tt.want {
+				t.Errorf("DisplayName(%q) = %q, want %q", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "txn_abc123", want: "txn_abc123"},
+		{query: "房間", want: `" 房 間 "`},
+		{query: "退款 txn_1", want: `" 退 款 " txn_1`},
+		{query: `"大廳房間 5" -測試`, want: `" 大 廳 房 間 5" -" 測 試 "`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := gamelog.SearchQuery(tt.query); got != tt.want {
+				t.Errorf("SearchQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
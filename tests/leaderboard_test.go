@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetLeaderboard(t *testing.T) {
+	router := setupTestEnvironment(t)
+
+	player := models.Player{
+		Name:  fmt.Sprintf("Test Player %d", time.Now().UnixNano()),
+		Level: 3,
+	}
+	if err := database.DB.Create(&player).Error; err != nil {
+		t.Fatalf("Failed to create test player: %v", err)
+	}
+	database.DB.Create(&models.Challenge{PlayerID: player.ID, Amount: 20.01})
+	database.DB.Create(&models.Challenge{PlayerID: player.ID, Amount: 500, IsWinner: true})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantTop    float64
+	}{
+		{
+			name:       "Biggest Wins All Time",
+			path:       "/leaderboards/biggest-wins",
+			wantStatus: http.StatusOK,
+			wantTop:    500,
+		},
+		{
+			name:       "Most Entries Today",
+			path:       "/leaderboards/most-entries?period=day",
+			wantStatus: http.StatusOK,
+			wantTop:    2,
+		},
+		{
+			name:       "Most Wins By Level",
+			path:       "/leaderboards/most-wins?period=week&level=3",
+			wantStatus: http.StatusOK,
+			wantTop:    1,
+		},
+		{
+			name:       "Unknown Board",
+			path:       "/leaderboards/richest",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Invalid Period",
+			path:       "/leaderboards/most-wins?period=year",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("GetLeaderboard() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			if w.Code == http.StatusOK {
+				var response struct {
+					Entries []struct {
+						PlayerID uint    `json:"player_id"`
+						Score    float64 `json:"score"`
+					} `json:"entries"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+				if len(response.Entries) == 0 {
+					t.Fatal("GetLeaderboard() returned no entries")
+				}
+				if response.Entries[0].Score != tt.wantTop {
+					t.Errorf("GetLeaderboard() top score = %v, want %v", response.Entries[0].Score, tt.wantTop)
+				}
+			}
+		})
+	}
+}
+
+func TestBiggestWinsLeaderboardOrder(t *testing.T) {
+	router := setupTestEnvironment(t)
+	cleanupDatabase()
+
+	players := make([]models.Player, 3)
+	for i := range players {
+		players[i] = models.Player{Name: fmt.Sprintf("Test Player %d", time.Now().UnixNano()), Level: 1}
+		if err := database.DB.Create(&players[i]).Error; err != nil {
+			t.Fatalf("Failed to create test player: %v", err)
+		}
+	}
+
+	// All three won 150 in total. The last player counts the mega-jackpot and
+	// got there first; the other two tie on time and fall back to player ID.
+	base := time.Now().Add(-time.Hour)
+	wins := []models.Challenge{
+		{PlayerID: players[0].ID, Amount: 150, IsWinner: true},
+		{PlayerID: players[1].ID, Amount: 150, IsWinner: true},
+		{PlayerID: players[2].ID, Amount: 100, IsWinner: true, IsJackpotWinner: true, JackpotAmount: 50},
+	}
+	for i := range wins {
+		wins[i].CreatedAt = base.Add(time.Minute)
+		if i == 2 {
+			wins[i].CreatedAt = base
+		}
+		database.DB.Create(&wins[i])
+	}
+	want := []uint{players[2].ID, players[0].ID, players[1].ID}
+
+	// The second request is served from the cache when Redis is available
+	for _, attempt := range []string{"first", "second"} {
+		req := httptest.NewRequest("GET", "/leaderboards/biggest-wins", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetLeaderboard() status = %v, want %v", w.Code, http.StatusOK)
+		}
+
+		var response struct {
+			Entries []struct {
+				PlayerID uint    `json:"player_id"`
+				Score    float64 `json:"score"`
+			} `json:"entries"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		var got []uint
+		for _, entry := range response.Entries {
+			got = append(got, entry.PlayerID)
+			if entry.Score != 150 {
+				t.Errorf("%s request: player %d score = %v, want 150", attempt, entry.PlayerID, entry.Score)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s request: order = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestLeaderboardCacheFollowsNewEntries(t *testing.T) {
+	router := setupTestEnvironment(t)
+	cleanupDatabase()
+	playerID := setupTestChallenge(t)
+	database.DB.Create(&models.Challenge{PlayerID: playerID, Amount: 20.01})
+
+	topScore := func() float64 {
+		req := httptest.NewRequest("GET", "/leaderboards/most-entries?period=day", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetLeaderboard() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		var response struct {
+			Entries []struct {
+				PlayerID uint    `json:"player_id"`
+				Score    float64 `json:"score"`
+			} `json:"entries"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Entries) != 1 || response.Entries[0].PlayerID != playerID {
+			t.Fatalf("GetLeaderboard() entries = %+v, want only player %d", response.Entries, playerID)
+		}
+		return response.Entries[0].Score
+	}
+
+	if score := topScore(); score != 1 {
+		t.Errorf("Entries before joining = %v, want 1", score)
+	}
+
+	// The cached board counts the new entry without waiting to expire
+	if w := sendJSON(router, "POST", "/challenges", map[string]interface{}{"player_id": playerID, "amount": 20.01}); w.Code != http.StatusCreated {
+		t.Fatalf("JoinChallenge() status = %v, want %v, response = %v", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if score := topScore(); score != 2 {
+		t.Errorf("Entries after joining = %v, want 2", score)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"interview_Ping_20241219/internal/cache"
+	"testing"
+	"time"
+)
+
+func TestMemoryLockStoreCooldown(t *testing.T) {
+	store := cache.NewMemoryLockStore()
+	ctx := context.Background()
+
+	started, _, err := store.StartCooldown(ctx, "cooldown", time.Minute)
+	if err != nil || !started {
+		t.Fatalf("StartCooldown() = %v, %v, want started", started, err)
+	}
+
+	started, remaining, _ := store.StartCooldown(ctx, "cooldown", time.Minute)
+	if started || remaining <= 0 || remaining > time.Minute {
+		t.Errorf("StartCooldown() during cooldown = %v, %v, want not started with remaining time", started, remaining)
+	}
+
+	store.ClearCooldown(ctx, "cooldown")
+	if started, _, _ := store.StartCooldown(ctx, "cooldown", time.Minute); !started {
+		t.Error("StartCooldown() after ClearCooldown did not start")
+	}
+
+	started, _, _ = store.StartCooldown(ctx, "short", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if started, _, _ = store.StartCooldown(ctx, "short", time.Millisecond); !started {
+		t.Error("StartCooldown() after expiry did not start")
+	}
+}
+
+func TestMemoryLockStoreLock(t *testing.T) {
+	store := cache.NewMemoryLockStore()
+
+	unlock, err := store.Lock(context.Background(), "lock", time.Minute)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := store.Lock(ctx, "lock", time.Minute); !errors.Is(err, cache.ErrLockNotAcquired) {
+		t.Errorf("Lock() while held error = %v, want %v", err, cache.ErrLockNotAcquired)
+	}
+
+	unlock()
+	relock, err := store.Lock(context.Background(), "lock", time.Minute)
+	if err != nil {
+		t.Fatalf("Lock() after unlock error = %v", err)
+	}
+
+	// A stale unlock must not release a lock held by someone else
+	unlock()
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := store.Lock(ctx, "lock", time.Minute); !errors.Is(err, cache.ErrLockNotAcquired) {
+		t.Errorf("Lock() after stale unlock error = %v, want %v", err, cache.ErrLockNotAcquired)
+	}
+	relock()
+}
+
+func TestMemoryLockStoreSweepsExpiredKeys(t *testing.T) {
+	store := cache.NewMemoryLockStore()
+	ctx := context.Background()
+
+	for i := 0; i < 1000; i++ {
+		store.StartCooldown(ctx, fmt.Sprintf("expired:%d", i), time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	for i := 0; i < 100; i++ {
+		store.StartCooldown(ctx, fmt.Sprintf("live:%d", i), time.Minute)
+	}
+
+	if n := store.Len(); n > 100 {
+		t.Errorf("Len() = %d after the expired keys were due for a sweep, want at most 100", n)
+	}
+	if started, _, _ := store.StartCooldown(ctx, "live:0", time.Minute); started {
+		t.Error("StartCooldown() on a live key started after a sweep")
+	}
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestListLogActions(t *testing.T) {
+	router := setupTestEnvironment(t)
+
+	req := httptest.NewRequest("GET", "/logs/actions", nil)
+	req.Header.Set("Accept-Language", "zh-TW,zh;q=0.9,en;q=0.8")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("ListLogActions() status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Locale  string `json:"locale"`
+		Actions []struct {
+			Code       string `json:"code"`
+			Name       string `json:"name"`
+			LegacyName string `json:"legacy_name"`
+			ServerOnly bool   `json:"server_only"`
+		} `json:"actions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Locale != "zh-TW" {
+		t.Errorf("ListLogActions() locale = %v, want zh-TW", response.Locale)
+	}
+
+	found := false
+	for _, action := range response.Actions {
+		if action.Code == string(models.ActionLogin) {
+			found = true
+			if action.Name != "登入" || action.LegacyName != "登入" || action.ServerOnly {
+				t.Errorf("Login action = %+v, want name and legacy name 登入, reported by clients", action)
+			}
+		}
+	}
+	if !found {
+		t.Error("ListLogActions() is missing the login action")
+	}
+}
+
+func TestCreateLogAction(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+	code := fmt.Sprintf("level_up_%d", time.Now().UnixNano())
+
+	tests := []struct {
+		name       string
+		payload    map[string]interface{}
+		token      string
+		wantStatus int
+	}{
+		{
+			name: "Not Admin",
+			payload: map[string]interface{}{
+				"code":  code,
+				"names": map[string]string{"en": "Level up"},
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Valid Action",
+			payload: map[string]interface{}{
+				"code":   code,
+				"names":  map[string]string{"en": "Level up", "zh-TW": "升級"},
+				"fields": map[string]interface{}{"level": map[string]interface{}{"type": "integer", "required": true}},
+			},
+			token:      "test-admin-token",
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "Duplicate Code",
+			payload: map[string]interface{}{
+				"code":  code,
+				"names": map[string]string{"en": "Level up"},
+			},
+			token:      "test-admin-token",
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "Built-in Code",
+			payload: map[string]interface{}{
+				"code":  "login",
+				"names": map[string]string{"en": "Log in"},
+			},
+			token:      "test-admin-token",
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "Invalid Code",
+			payload: map[string]interface{}{
+				"code":  "升級",
+				"names": map[string]string{"en": "Level up"},
+			},
+			token:      "test-admin-token",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Missing English Name",
+			payload: map[string]interface{}{
+				"code":  code + "_x",
+				"names": map[string]string{"zh-TW": "升級"},
+			},
+			token:      "test-admin-token",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unknown Field Type",
+			payload: map[string]interface{}{
+				"code":   code + "_y",
+				"names":  map[string]string{"en": "Level up"},
+				"fields": map[string]interface{}{"level": map[string]interface{}{"type": "date"}},
+			},
+			token:      "test-admin-token",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, sendAdminJSON("POST", "/logs/actions", tt.payload, tt.token))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateLogAction() status = %v, want %v, response = %v", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	// The new action can be reported right away and its schema is enforced
+	w := sendJSON(router, "POST", "/logs", map[string]interface{}{
+		"player_id": playerID,
+		"action":    code,
+		"details":   map[string]interface{}{"level": 2},
+	})
+	if w.Code != http.StatusCreated {
+		t.Errorf("CreateLog() with a registered action status = %v, want %v, response = %v", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	w = sendJSON(router, "POST", "/logs", map[string]interface{}{
+		"player_id": playerID,
+		"action":    code,
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("CreateLog() without a required field status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCreateLogWithLegacyAction(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+
+	w := sendJSON(router, "POST", "/logs", map[string]interface{}{
+		"player_id": playerID,
+		"action":    "登入",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateLog() status = %v, want %v, response = %v", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	var created models.GameLog
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Action != models.ActionLogin {
+		t.Errorf("CreateLog() action = %v, want %v", created.Action, models.ActionLogin)
+	}
+
+	// Filtering by either form finds the log
+	for _, action := range []string{"login", "登入"} {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/logs?player_id=%d&action=%s", playerID, url.QueryEscape(action)), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Logs []models.GameLog `json:"logs"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Logs) != 1 {
+			t.Errorf("GetLogs(action=%s) returned %d logs, want 1", action, len(response.Logs))
+		}
+	}
+}
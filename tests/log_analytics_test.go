@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"encoding/json"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// setupTestAnalyticsLogs writes two players' logs on 2026-03-10 in Taipei.
+// The second player registers late in the evening and logs in after
+// midnight, so their login falls on a different day in UTC+8 than in UTC.
+func setupTestAnalyticsLogs(t *testing.T) {
+	taipei, _ := time.LoadLocation("Asia/Taipei")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, taipei)
+	}
+
+	first := setupTestLog(t)
+	second := setupTestLog(t)
+	logs := []models.GameLog{
+		{PlayerID: first, Action: models.ActionRegister, Details: models.LogDetails{"name": "first"}, CreatedAt: at(10, 9, 0)},
+		{PlayerID: first, Action: models.ActionLogin, CreatedAt: at(10, 9, 5)},
+		{PlayerID: first, Action: models.ActionEnterRoom, Details: models.LogDetails{"room_id": 7}, CreatedAt: at(10, 9, 10)},
+		{PlayerID: first, Action: models.ActionJoinChallenge, Details: models.LogDetails{"challenge_id": 1, "amount": 20.01}, CreatedAt: at(10, 9, 15)},
+		{PlayerID: first, Action: models.ActionLeaveRoom, Details: models.LogDetails{"room_id": 7}, CreatedAt: at(10, 9, 20)},
+		{PlayerID: first, Action: models.ActionLogout, CreatedAt: at(10, 9, 35)},
+		{PlayerID: second, Action: models.ActionRegister, Details: models.LogDetails{"name": "second"}, CreatedAt: at(10, 23, 30)},
+		{PlayerID: second, Action: models.ActionLogin, CreatedAt: at(11, 0, 10)},
+	}
+	if err := gamelog.WriteBatch(database.DB, logs); err != nil {
+		t.Fatalf("Failed to create test logs: %v", err)
+	}
+}
+
+func getAnalytics(t *testing.T, router http.Handler, path string, response interface{}) int {
+	req := httptest.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+	}
+	return w.Code
+}
+
+func TestGetActivePlayers(t *testing.T) {
+	router := setupTestEnvironment(t)
+	setupTestAnalyticsLogs(t)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantDAU    []int64
+		wantMAU    []int64
+	}{
+		{
+			name:       "Taipei Days",
+			query:      "?start_date=2026-03-10&end_date=2026-03-11&tz=Asia/Taipei",
+			wantStatus: http.StatusOK,
+			wantDAU:    []int64{1, 1},
+			wantMAU:    []int64{1, 2},
+		},
+		{
+			name:       "UTC Days",
+			query:      "?start_date=2026-03-10&end_date=2026-03-11",
+			wantStatus: http.StatusOK,
+			wantDAU:    []int64{2, 0},
+			wantMAU:    []int64{2, 2},
+		},
+		{
+			name:       "Invalid Timezone",
+			query:      "?tz=Mars/Olympus",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "End Before Start",
+			query:      "?start_date=2026-03-11&end_date=2026-03-10",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Range Too Long",
+			query:      "?start_date=2024-01-01&end_date=2026-03-10",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response struct {
+				Days []struct {
+					DAU int64 `json:"dau"`
+					MAU int64 `json:"mau"`
+				} `json:"days"`
+			}
+			status := getAnalytics(t, router, "/logs/analytics/active"+tt.query, &response)
+			if status != tt.wantStatus {
+				t.Fatalf("GetActivePlayers() status = %v, want %v", status, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if len(response.Days) != len(tt.wantDAU) {
+				t.Fatalf("GetActivePlayers() returned %d days, want %d", len(response.Days), len(tt.wantDAU))
+			}
+			for i, day := range response.Days {
+				if day.DAU != tt.wantDAU[i] || day.MAU != tt.wantMAU[i] {
+					t.Errorf("Day %d DAU/MAU = %d/%d, want %d/%d", i, day.DAU, day.MAU, tt.wantDAU[i], tt.wantMAU[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetSessionAndRoomDurations(t *testing.T) {
+	router := setupTestEnvironment(t)
+	setupTestAnalyticsLogs(t)
+
+	var sessions struct {
+		Days []map[string]interface{} `json:"days"`
+	}
+	status := getAnalytics(t, router, "/logs/analytics/sessions?start_date=2026-03-10&end_date=2026-03-11&tz=Asia/Taipei", &sessions)
+	if status != http.StatusOK || len(sessions.Days) != 2 {
+		t.Fatalf("GetSessionLengths() status = %v, days = %d, want 200 and 2 days", status, len(sessions.Days))
+	}
+	if day := sessions.Days[0]; day["completed"] != float64(1) || day["avg_seconds"] != float64(1800) {
+		t.Errorf("First day sessions = %v, want one completed 1800s session", day)
+	}
+	if day := sessions.Days[1]; day["started"] != float64(1) || day["open"] != float64(1) || day["avg_seconds"] != nil {
+		t.Errorf("Second day sessions = %v, want one open session", day)
+	}
+
+	var rooms struct {
+		Days []map[string]interface{} `json:"days"`
+	}
+	status = getAnalytics(t, router, "/logs/analytics/rooms?start_date=2026-03-10&end_date=2026-03-10&tz=Asia/Taipei&room_id=7", &rooms)
+	if status != http.StatusOK || len(rooms.Days) != 1 {
+		t.Fatalf("GetRoomDwellTimes() status = %v, days = %d, want 200 and 1 day", status, len(rooms.Days))
+	}
+	if day := rooms.Days[0]; day["completed"] != float64(1) || day["total_seconds"] != float64(600) {
+		t.Errorf("Room dwell = %v, want one 600s visit", day)
+	}
+
+	status = getAnalytics(t, router, "/logs/analytics/rooms?room_id=abc", &rooms)
+	if status != http.StatusBadRequest {
+		t.Errorf("GetRoomDwellTimes() with invalid room_id status = %v, want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestGetChallengeFunnel(t *testing.T) {
+	router := setupTestEnvironment(t)
+	setupTestAnalyticsLogs(t)
+
+	var response struct {
+		Days  []map[string]interface{} `json:"days"`
+		Total map[string]interface{}   `json:"total"`
+	}
+	status := getAnalytics(t, router, "/logs/analytics/funnel?start_date=2026-03-10&end_date=2026-03-11&tz=Asia/Taipei", &response)
+	if status != http.StatusOK {
+		t.Fatalf("GetChallengeFunnel() status = %v, want %v", status, http.StatusOK)
+	}
+
+	want := map[string]float64{"registered": 2, "logged_in": 2, "joined_challenge": 1, "conversion": 0.5}
+	for key, value := range want {
+		if response.Total[key] != value {
+			t.Errorf("Funnel total %s = %v, want %v", key, response.Total[key], value)
+		}
+	}
+	if response.Days[1]["registered"] != float64(0) {
+		t.Errorf("Funnel second day registered = %v, want 0", response.Days[1]["registered"])
+	}
+}
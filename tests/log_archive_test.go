@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"crypto/ed25519"
+	"interview_Ping_20241219/internal/config"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/logarchive"
+	"interview_Ping_20241219/internal/models"
+	"testing"
+	"time"
+)
+
+func TestArchiveAndRestoreLogs(t *testing.T) {
+	setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+
+	now := time.Now()
+	threeYearsAgo := now.AddDate(-3, 0, 0)
+	if err := database.EnsureGameLogPartitions(database.DB, threeYearsAgo, threeYearsAgo); err != nil {
+		t.Fatalf("Failed to create partition: %v", err)
+	}
+
+	logs := []models.GameLog{
+		{PlayerID: playerID, Action: models.ActionEnterRoom, Details: models.LogDetails{"room_id": 1}, CreatedAt: threeYearsAgo},
+		{PlayerID: playerID, Action: models.ActionLogin, CreatedAt: now.AddDate(0, 0, -100)},
+		{PlayerID: playerID, Action: models.ActionRegister, Details: models.LogDetails{"name": "x"}, CreatedAt: now.AddDate(0, 0, -100)},
+		{PlayerID: playerID, Action: models.ActionLogin, CreatedAt: now},
+	}
+	if err := gamelog.WriteBatch(database.DB, logs); err != nil {
+		t.Fatalf("Failed to create logs: %v", err)
+	}
+
+	cfg := config.LogRetentionConfig{
+		DefaultDays: 730,
+		ActionDays:  map[string]int{string(models.ActionLogin): 90},
+		ArchiveDir:  t.TempDir(),
+	}
+	result, err := logarchive.Run(database.DB, cfg, now)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.DroppedPartitions) != 1 || result.ExpiredRows != 1 || len(result.Files) != 2 {
+		t.Fatalf("Run() = %+v, want 1 dropped partition, 1 expired row and 2 files", result)
+	}
+
+	var remaining int64
+	database.DB.Model(&models.GameLog{}).Where("player_id = ?", playerID).Count(&remaining)
+	if remaining != 2 {
+		t.Errorf("%d logs left after archival, want 2", remaining)
+	}
+
+	// Archived entries leave tombstones, so the chain still verifies
+	publicKey := config.GetLogSigningKey().Public().(ed25519.PublicKey)
+	if report, err := gamelog.Verify(database.DB, publicKey); err != nil || !report.Valid || report.Tombstones != 2 {
+		t.Errorf("Verify() after archival = %+v, %v, want valid with 2 tombstones", report, err)
+	}
+
+	for _, file := range result.Files {
+		if _, err := logarchive.Restore(database.DB, file); err != nil {
+			t.Fatalf("Restore(%s) error = %v", file, err)
+		}
+	}
+	// Restoring again skips rows that are already present
+	restored, err := logarchive.Restore(database.DB, result.Files[1])
+	if err != nil || restored != 0 {
+		t.Errorf("Second Restore() = %d, %v, want 0 rows", restored, err)
+	}
+
+	database.DB.Model(&models.GameLog{}).Where("player_id = ?", playerID).Count(&remaining)
+	if remaining != 4 {
+		t.Errorf("%d logs after restore, want 4", remaining)
+	}
+	if report, err := gamelog.Verify(database.DB, publicKey); err != nil || !report.Valid {
+		t.Errorf("Verify() after restore = %+v, %v, want valid", report, err)
+	}
+}
+
+func TestPartitionTakesRowsFromDefault(t *testing.T) {
+	setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+
+	// No partition covers this month yet, so the row lands in the default one
+	future := time.Now().UTC().AddDate(5, 0, 0)
+	partition := "game_logs_p" + future.Format("200601")
+	if err := database.DB.Exec("INSERT INTO game_logs (player_id, action, created_at) VALUES (?, ?, ?)",
+		playerID, models.ActionLogin, future).Error; err != nil {
+		t.Fatalf("Failed to create log: %v", err)
+	}
+	defer database.DB.Exec("DROP TABLE IF EXISTS " + partition)
+
+	if err := database.EnsureGameLogPartitions(database.DB, future, future); err != nil {
+		t.Fatalf("EnsureGameLogPartitions() error = %v", err)
+	}
+
+	var inPartition, inDefault int64
+	database.DB.Table(partition).Where("player_id = ?", playerID).Count(&inPartition)
+	database.DB.Table("game_logs_default").Where("player_id = ?", playerID).Count(&inDefault)
+	if inPartition != 1 || inDefault != 0 {
+		t.Errorf("Rows in %s = %d and in default = %d, want 1 and 0", partition, inPartition, inDefault)
+	}
+}
+
+func TestLogRetentionConfigFallsBackOnInvalidDays(t *testing.T) {
+	// A retention of zero or less would expire every log
+	for _, days := range []string{"0", "-30", "forever"} {
+		t.Setenv("LOG_RETENTION_DAYS", days)
+		if got := config.GetLogRetentionConfig().DefaultDays; got != 730 {
+			t.Errorf("DefaultDays with LOG_RETENTION_DAYS=%s = %d, want 730", days, got)
+		}
+	}
+	t.Setenv("LOG_RETENTION_DAYS", "365")
+	if got := config.GetLogRetentionConfig().DefaultDays; got != 365 {
+		t.Errorf("DefaultDays with LOG_RETENTION_DAYS=365 = %d, want 365", got)
+	}
+}
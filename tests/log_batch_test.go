@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"encoding/json"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCreateLogBatch(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+
+	w := sendJSON(router, "POST", "/logs/batch", map[string]interface{}{
+		"logs": []map[string]interface{}{
+			{"player_id": playerID, "action": "登入", "details": map[string]interface{}{"device": "ios"}},
+			{"player_id": playerID, "action": "進入房間", "details": map[string]interface{}{"room_id": 3}},
+			{"player_id": playerID, "action": "進入房間", "details": map[string]interface{}{}},
+			{"player_id": playerID, "action": "註冊", "details": map[string]interface{}{"name": "x"}},
+			{"player_id": 999999, "action": "登出"},
+			{"player_id": playerID, "action": "unknown"},
+		},
+	})
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("CreateLogBatch() status = %v, want %v, response = %v", w.Code, http.StatusMultiStatus, w.Body.String())
+	}
+
+	var response struct {
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+		Results   []struct {
+			Index int    `json:"index"`
+			ID    uint   `json:"id"`
+			Error string `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Succeeded != 1 || response.Failed != 5 {
+		t.Errorf("succeeded = %d, failed = %d, want 1 and 5", response.Succeeded, response.Failed)
+	}
+	for i, result := range response.Results {
+		wantOK := i < 1
+		if wantOK != (result.ID != 0 && result.Error == "") {
+			t.Errorf("Result %d = %+v, want success %v", i, result, wantOK)
+		}
+	}
+
+	w = sendJSON(router, "POST", "/logs/batch", map[string]interface{}{"logs": []map[string]interface{}{}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("CreateLogBatch() with empty batch status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCreateLogBatchAsync(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+
+	w := sendJSON(router, "POST", "/logs/batch?async=true", map[string]interface{}{
+		"logs": []map[string]interface{}{
+			{"player_id": playerID, "action": "登入"},
+			{"player_id": playerID, "action": "登出"},
+		},
+	})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("CreateLogBatch() status = %v, want %v, response = %v", w.Code, http.StatusAccepted, w.Body.String())
+	}
+
+	// The buffered writer flushes on an interval
+	deadline := time.Now().Add(5 * time.Second)
+	var count int64
+	for time.Now().Before(deadline) {
+		database.DB.Model(&models.GameLog{}).Where("player_id = ?", playerID).Count(&count)
+		if count == 2 {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Errorf("Found %d buffered logs, want 2", count)
+}
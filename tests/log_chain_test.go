@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func verifyLogs(t *testing.T, router *gin.Engine) gamelog.VerifyReport {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("GET", "/logs/verify", nil, "test-admin-token"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("VerifyLogs() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+	var report gamelog.VerifyReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return report
+}
+
+func hasPlayerIssue(report gamelog.VerifyReport, playerID uint, issueType string) bool {
+	for _, issue := range report.Issues {
+		if issue.PlayerID == playerID && issue.Type == issueType {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLogHashChain(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+	otherPlayerID := setupTestLog(t)
+
+	// Each player has their own chain, so the other player's logs in between
+	// do not take sequence numbers from it
+	for i := 0; i < 3; i++ {
+		for _, id := range []uint{playerID, otherPlayerID} {
+			w := sendJSON(router, "POST", "/logs", map[string]interface{}{
+				"player_id": id,
+				"action":    "登入",
+				"details":   map[string]interface{}{"device": fmt.Sprintf("device-%d", i)},
+			})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("CreateLog() status = %v, want %v", w.Code, http.StatusCreated)
+			}
+		}
+	}
+	w := sendJSON(router, "POST", "/logs/batch", map[string]interface{}{
+		"logs": []map[string]interface{}{
+			{"player_id": playerID, "action": "登出"},
+			{"player_id": playerID, "action": "登入"},
+		},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateLogBatch() status = %v, want %v", w.Code, http.StatusCreated)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("POST", "/logs/checkpoints", nil, "test-admin-token"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateLogCheckpoint() status = %v, want %v, response = %v", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	report := verifyLogs(t, router)
+	if !report.Valid || report.Chains != 2 || report.Entries != 8 || report.Checkpoints != 2 {
+		t.Fatalf("Verify() = %+v, want 2 valid chains of 8 entries with a checkpoint each", report)
+	}
+	var lastSeq uint64
+	database.DB.Model(&models.GameLog{}).Where("player_id = ?", playerID).Select("MAX(seq)").Scan(&lastSeq)
+	if lastSeq != 5 {
+		t.Errorf("Last sequence number of the player = %d, want 5", lastSeq)
+	}
+
+	database.DB.Exec(`UPDATE game_logs SET details = '{"device": "edited"}' WHERE player_id = ? AND seq = 2`, playerID)
+	report = verifyLogs(t, router)
+	if report.Valid || !hasPlayerIssue(report, playerID, "modified") {
+		t.Errorf("Verify() after edit = %+v, want a modified entry", report)
+	}
+
+	database.DB.Exec("DELETE FROM game_logs WHERE player_id = ? AND seq = 3", playerID)
+	report = verifyLogs(t, router)
+	if !hasPlayerIssue(report, playerID, "missing") {
+		t.Errorf("Verify() after delete = %+v, want a missing entry", report)
+	}
+
+	database.DB.Exec("DELETE FROM game_logs WHERE player_id = ? AND seq = 5", playerID)
+	report = verifyLogs(t, router)
+	if !hasPlayerIssue(report, playerID, "truncated") {
+		t.Errorf("Verify() after deleting the last entry = %+v, want truncation", report)
+	}
+
+	// The other player's chain is untouched
+	for _, issue := range report.Issues {
+		if issue.PlayerID == otherPlayerID {
+			t.Errorf("Verify() reported %+v for the other player", issue)
+		}
+	}
+}
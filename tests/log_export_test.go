@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExportLogs(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+
+	for i := 0; i < 3; i++ {
+		database.DB.Create(&models.GameLog{
+			PlayerID: playerID,
+			Action:   models.ActionEnterRoom,
+			Details:  models.LogDetails{"room_id": i + 1},
+		})
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/logs/export?format=csv&player_id=%d", playerID), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ExportLogs() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		if !strings.Contains(w.Header().Get("Content-Disposition"), ".csv") {
+			t.Errorf("Content-Disposition = %q, want a .csv filename", w.Header().Get("Content-Disposition"))
+		}
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v", err)
+		}
+		if len(records) != 4 {
+			t.Errorf("CSV has %d rows, want header and 3 logs", len(records))
+		}
+	})
+
+	t.Run("NDJSON Gzip With Details Filter", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/logs/export?format=ndjson&player_id=%d&details.room_id=2", playerID), nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("ExportLogs() status = %v, encoding = %q, want 200 gzip", w.Code, w.Header().Get("Content-Encoding"))
+		}
+		gz, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("Failed to open gzip body: %v", err)
+		}
+		var lines []map[string]interface{}
+		scanner := bufio.NewScanner(gz)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("Failed to parse NDJSON line: %v", err)
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) != 1 {
+			t.Errorf("NDJSON has %d lines, want 1", len(lines))
+		}
+	})
+
+	t.Run("Invalid Format", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/logs/export?format=xml", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ExportLogs() status = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/gamelog"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSearchLogs(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+	otherPlayerID := setupTestLog(t)
+
+	logs := []models.GameLog{
+		{PlayerID: playerID, Action: models.ActionLogin, Details: models.LogDetails{"message": "退款完成 txn_AB12CD"}},
+		{PlayerID: playerID, Action: models.ActionLogin, Details: models.LogDetails{"message": "進入大廳房間"}},
+		{PlayerID: otherPlayerID, Action: models.ActionLogin, Details: models.LogDetails{"message": "大廳房間 txn_AB12CD"}},
+		{PlayerID: playerID, Action: models.ActionLogin, Details: models.LogDetails{"message": "房 and 間 far apart"}},
+	}
+	if err := gamelog.WriteBatch(database.DB, logs); err != nil {
+		t.Fatalf("Failed to create test logs: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCount  int
+		wantMark   string
+	}{
+		{
+			name:       "Transaction ID",
+			query:      fmt.Sprintf("q=txn_ab12cd&player_id=%d", playerID),
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+			wantMark:   "<mark>txn_AB12CD</mark>",
+		},
+		{
+			name:       "Chinese Phrase",
+			query:      fmt.Sprintf("q=%s&player_id=%d", url.QueryEscape("房間"), playerID),
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+			wantMark:   "<mark>房間</mark>",
+		},
+		{
+			name:       "All Players",
+			query:      "q=" + url.QueryEscape("大廳房間"),
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+		},
+		{
+			name:       "Negated Term",
+			query:      "q=" + url.QueryEscape("大廳 -txn_ab12cd"),
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+		},
+		{
+			name:       "Missing Query",
+			query:      "q=",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid Offset",
+			query:      "q=txn&offset=-1",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/logs/search?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("SearchLogs() status = %v, want %v, response = %v", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Results []struct {
+					Log       models.GameLog `json:"log"`
+					Highlight string         `json:"highlight"`
+				} `json:"results"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if len(response.Results) != tt.wantCount {
+				t.Fatalf("SearchLogs() returned %d results, want %d", len(response.Results), tt.wantCount)
+			}
+			if tt.wantMark != "" && !strings.Contains(response.Results[0].Highlight, tt.wantMark) {
+				t.Errorf("Highlight = %q, want it to contain %q", response.Results[0].Highlight, tt.wantMark)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/logarchive"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamLogs(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+	otherPlayerID := setupTestLog(t)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/logs/stream?player_id=%d", server.URL, playerID))
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		t.Fatalf("StreamLogs() content type = %v, want text/event-stream", contentType)
+	}
+
+	players := make(chan float64, 16)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			var event struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			if json.Unmarshal([]byte(data), &event) == nil {
+				players <- event.Data["player_id"].(float64)
+			}
+		}
+		close(players)
+	}()
+
+	createLog := func(id uint) {
+		payload, _ := json.Marshal(map[string]interface{}{"player_id": id, "action": "登入"})
+		logResp, err := http.Post(server.URL+"/logs", "application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("Failed to create log: %v", err)
+		}
+		logResp.Body.Close()
+	}
+
+	// The listener may still be connecting, so keep logging until one arrives
+	timeout := time.After(10 * time.Second)
+	tick := time.NewTicker(500 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		createLog(otherPlayerID)
+		createLog(playerID)
+		select {
+		case got, ok := <-players:
+			if !ok {
+				t.Fatal("Stream closed before a log event")
+			}
+			if uint(got) != playerID {
+				t.Fatalf("Received log of player %v, want only player %v", got, playerID)
+			}
+			return
+		case <-tick.C:
+		case <-timeout:
+			t.Fatal("Timed out waiting for a log event")
+		}
+	}
+}
+
+func TestStreamLogsSkipsRestoredLogs(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/logs/stream?player_id=%d", server.URL, playerID))
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	ids := make(chan float64, 16)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			var event struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			if json.Unmarshal([]byte(data), &event) == nil {
+				ids <- event.Data["id"].(float64)
+			}
+		}
+		close(ids)
+	}()
+
+	createLog := func() float64 {
+		payload, _ := json.Marshal(map[string]interface{}{"player_id": playerID, "action": "login"})
+		logResp, err := http.Post(server.URL+"/logs", "application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("Failed to create log: %v", err)
+		}
+		defer logResp.Body.Close()
+		var created struct {
+			ID float64 `json:"id"`
+		}
+		json.NewDecoder(logResp.Body).Decode(&created)
+		return created.ID
+	}
+
+	// Wait for the listener to connect before restoring
+	timeout := time.After(10 * time.Second)
+	tick := time.NewTicker(500 * time.Millisecond)
+	defer tick.Stop()
+connected:
+	for {
+		createLog()
+		select {
+		case <-ids:
+			break connected
+		case <-tick.C:
+		case <-timeout:
+			t.Fatal("Timed out waiting for a log event")
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "restore.ndjson.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	gz := gzip.NewWriter(file)
+	restoredID := uint(time.Now().UnixNano() % 1000000000)
+	json.NewEncoder(gz).Encode(logarchive.Record{
+		ID:        restoredID,
+		PlayerID:  playerID,
+		Action:    models.ActionLogin,
+		CreatedAt: time.Now().AddDate(0, -1, 0),
+	})
+	gz.Close()
+	file.Close()
+	if _, err := logarchive.Restore(database.DB, path); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	// Notifications arrive in commit order, so a restored row would come
+	// before the next live one
+	liveID := createLog()
+	for {
+		select {
+		case id, ok := <-ids:
+			if !ok {
+				t.Fatal("Stream closed before a log event")
+			}
+			if uint(id) == restoredID {
+				t.Fatalf("Stream sent restored log %d", restoredID)
+			}
+			if id == liveID {
+				return
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("Timed out waiting for a log event")
+		}
+	}
+}
@@ -34,13 +34,49 @@ func TestCreateLog(t *testing.T) {
 		wantStatus int
 	}{
 		{
-			name: "Valid Log - Register",
+			name: "Valid Log - Login",
+			payload: map[string]interface{}{
+				"player_id": playerID,
+				"action":    "登入",
+				"details":   "Player logged in",
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "Server Action - Enter Room",
+			payload: map[string]interface{}{
+				"player_id": playerID,
+				"action":    "進入房間",
+				"details":   map[string]interface{}{"room_id": 5},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Invalid Details - Missing Room",
+			payload: map[string]interface{}{
+				"player_id": playerID,
+				"action":    "進入房間",
+				"details":   map[string]interface{}{"device": "ios"},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid Details - Wrong Type",
+			payload: map[string]interface{}{
+				"player_id": playerID,
+				"action":    "退出房間",
+				"details":   map[string]interface{}{"room_id": "five"},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Server Action - Register",
 			payload: map[string]interface{}{
 				"player_id": playerID,
 				"action":    "註冊",
 				"details":   "New player registration",
 			},
-			wantStatus: http.StatusCreated,
+			wantStatus: http.StatusForbidden,
 		},
 		{
 			name: "Invalid Log - Missing Action",
@@ -76,7 +112,7 @@ func TestGetLogs(t *testing.T) {
 	testLog := models.GameLog{
 		PlayerID: playerID,
 		Action:   models.ActionRegister,
-		Details:  "Test registration",
+		Details:  models.LogDetails{"name": "Test Player"},
 	}
 	database.DB.Create(&testLog)
 
@@ -100,6 +136,36 @@ func TestGetLogs(t *testing.T) {
 			query:      "?action=註冊",
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:       "Filter by Details Field",
+			query:      "?details.name=Test%20Player",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Filter by Unknown Details Field",
+			query:      "?details.unknown=1",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Filter by Invalid Details Value",
+			query:      "?details.room_id=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Limit Too Large",
+			query:      "?limit=1000000",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid Cursor",
+			query:      "?cursor=not-a-cursor",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid Order",
+			query:      "?order=sideways",
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name: "Filter by Time Range",
 			query: fmt.Sprintf("?start_time=%s&end_time=%s",
@@ -120,7 +186,9 @@ func TestGetLogs(t *testing.T) {
 			}
 
 			if w.Code == http.StatusOK {
-				var response []models.GameLog
+				var response struct {
+					Logs []models.GameLog `json:"logs"`
+				}
 				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 					t.Fatalf("Failed to parse response: %v", err)
 				}
@@ -128,3 +196,101 @@ func TestGetLogs(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLogsCursorPagination(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestLog(t)
+
+	for i := 0; i < 5; i++ {
+		database.DB.Create(&models.GameLog{
+			PlayerID: playerID,
+			Action:   models.ActionLogin,
+			Details:  models.LogDetails{"message": fmt.Sprintf("login %d", i)},
+		})
+	}
+
+	for _, order := range []string{"asc", "desc"} {
+		t.Run(order, func(t *testing.T) {
+			seen := map[uint]bool{}
+			var lastID uint
+			cursor := ""
+			for page := 0; page < 3; page++ {
+				query := fmt.Sprintf("/logs?player_id=%d&limit=2&order=%s&include_total=true", playerID, order)
+				if cursor != "" {
+					query += "&cursor=" + cursor
+				}
+				req := httptest.NewRequest("GET", query, nil)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					t.Fatalf("GetLogs() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+				}
+
+				var response struct {
+					Logs       []models.GameLog `json:"logs"`
+					NextCursor *string          `json:"next_cursor"`
+					Total      int64            `json:"total"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+				if response.Total != 5 {
+					t.Errorf("total = %d, want 5", response.Total)
+				}
+				for _, log := range response.Logs {
+					if seen[log.ID] {
+						t.Errorf("Log %d returned twice", log.ID)
+					}
+					if lastID != 0 && (order == "asc") != (log.ID > lastID) {
+						t.Errorf("Log %d out of %s order after %d", log.ID, order, lastID)
+					}
+					seen[log.ID] = true
+					lastID = log.ID
+				}
+
+				if page == 2 {
+					if response.NextCursor != nil {
+						t.Errorf("Last page next_cursor = %v, want null", *response.NextCursor)
+					}
+				} else if response.NextCursor == nil {
+					t.Fatalf("Page %d next_cursor is null, want a cursor", page+1)
+				} else {
+					cursor = *response.NextCursor
+				}
+			}
+			if len(seen) != 5 {
+				t.Errorf("Paged through %d logs, want 5", len(seen))
+			}
+		})
+	}
+}
+
+func TestAutomaticGameLogs(t *testing.T) {
+	router := setupTestEnvironment(t)
+
+	w := sendJSON(router, "POST", "/players", map[string]interface{}{
+		"name":  fmt.Sprintf("Test Player %d", time.Now().UnixNano()),
+		"level": 1,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreatePlayer() status = %v, want %v", w.Code, http.StatusCreated)
+	}
+	var player models.Player
+	json.Unmarshal(w.Body.Bytes(), &player)
+
+	w = sendJSON(router, "POST", "/challenges", map[string]interface{}{
+		"player_id": player.ID,
+		"amount":    20.01,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("JoinChallenge() status = %v, want %v", w.Code, http.StatusCreated)
+	}
+
+	for _, action := range []models.LogActionType{models.ActionRegister, models.ActionJoinChallenge, models.ActionChallengeEnd} {
+		var count int64
+		database.DB.Model(&models.GameLog{}).Where("player_id = ? AND action = ?", player.ID, action).Count(&count)
+		if count != 1 {
+			t.Errorf("Found %d %q logs, want 1", count, action)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func createTestReservation(t *testing.T, router *gin.Engine, roomID, playerID uint, start, end time.Time) models.Reservation {
+	w := sendJSON(router, "POST", "/reservations", map[string]interface{}{
+		"room_id":    roomID,
+		"player_id":  playerID,
+		"date":       start.Format("2006-01-02"),
+		"start_time": start.Format("15:04"),
+		"end_time":   end.Format("15:04"),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateReservation() status = %v, want %v, response = %v", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var reservation models.Reservation
+	if err := json.Unmarshal(w.Body.Bytes(), &reservation); err != nil {
+		t.Fatalf("Failed to parse reservation: %v", err)
+	}
+	return reservation
+}
+
+func TestReservationLifecycle(t *testing.T) {
+	router := setupTestEnvironment(t)
+	cleanupDatabase()
+
+	roomID, playerID := setupTestReservationData(t)
+	day := time.Now().UTC().AddDate(0, 0, 3).Truncate(24 * time.Hour)
+
+	first := createTestReservation(t, router, roomID, playerID, day.Add(10*time.Hour), day.Add(11*time.Hour))
+	second := createTestReservation(t, router, roomID, playerID, day.Add(12*time.Hour), day.Add(13*time.Hour))
+	if first.Status != models.ReservationConfirmed {
+		t.Errorf("New reservation status = %q, want %q", first.Status, models.ReservationConfirmed)
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/reservations/%d", first.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GetReservation() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	req = httptest.NewRequest("GET", "/reservations/999999", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GetReservation() with unknown ID status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+
+	moves := []struct {
+		name       string
+		payload    map[string]interface{}
+		wantStatus int
+	}{
+		{"Onto Another Reservation", map[string]interface{}{"start_time": "12:30", "end_time": "13:30"}, http.StatusConflict},
+		{"End Before Start", map[string]interface{}{"start_time": "15:00", "end_time": "14:00"}, http.StatusBadRequest},
+		{"Invalid Time", map[string]interface{}{"start_time": "3pm"}, http.StatusBadRequest},
+		{"Free Slot", map[string]interface{}{"start_time": "14:00", "end_time": "15:00"}, http.StatusOK},
+		{"Within Own Slot", map[string]interface{}{"end_time": "14:30"}, http.StatusOK},
+	}
+	for _, tt := range moves {
+		t.Run(tt.name, func(t *testing.T) {
+			w := sendJSON(router, "PATCH", fmt.Sprintf("/reservations/%d", first.ID), tt.payload)
+			if w.Code != tt.wantStatus {
+				t.Errorf("UpdateReservation() status = %v, want %v, response = %v", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	// Cancelling well ahead is free and releases the slot
+	w = sendJSON(router, "POST", fmt.Sprintf("/reservations/%d/cancel", second.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("CancelReservation() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+	var cancelled models.Reservation
+	json.Unmarshal(w.Body.Bytes(), &cancelled)
+	if cancelled.Status != models.ReservationCancelled || cancelled.CancellationFee != 0 {
+		t.Errorf("Cancelled reservation = %s with fee %v, want %s with no fee", cancelled.Status, cancelled.CancellationFee, models.ReservationCancelled)
+	}
+	if w := sendJSON(router, "POST", fmt.Sprintf("/reservations/%d/cancel", second.ID), nil); w.Code != http.StatusConflict {
+		t.Errorf("CancelReservation() twice status = %v, want %v", w.Code, http.StatusConflict)
+	}
+	if w := sendJSON(router, "PATCH", fmt.Sprintf("/reservations/%d", second.ID), map[string]interface{}{"start_time": "16:00", "end_time": "17:00"}); w.Code != http.StatusConflict {
+		t.Errorf("UpdateReservation() of a cancelled reservation status = %v, want %v", w.Code, http.StatusConflict)
+	}
+	createTestReservation(t, router, roomID, playerID, day.Add(12*time.Hour), day.Add(13*time.Hour))
+
+	req = httptest.NewRequest("GET", fmt.Sprintf("/reservations?room_id=%d&status=cancelled", roomID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var listed []models.Reservation
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if len(listed) != 1 || listed[0].ID != second.ID {
+		t.Errorf("ListReservations(status=cancelled) = %d reservations, want only %d", len(listed), second.ID)
+	}
+
+	// Checking in is only open shortly before the start
+	if w := sendJSON(router, "POST", fmt.Sprintf("/reservations/%d/check-in", first.ID), nil); w.Code != http.StatusConflict {
+		t.Errorf("CheckInReservation() days ahead status = %v, want %v", w.Code, http.StatusConflict)
+	}
+}
+
+func TestCancelReservationLate(t *testing.T) {
+	router := setupTestEnvironment(t)
+	cleanupDatabase()
+
+	roomID, playerID := setupTestReservationData(t)
+
+	// Starts within the next two hours, inside the cancellation cutoff
+	start := time.Now().UTC().Add(2 * time.Hour).Truncate(time.Hour)
+	reservation := createTestReservation(t, router, roomID, playerID, start, start.Add(30*time.Minute))
+
+	w := sendJSON(router, "POST", fmt.Sprintf("/reservations/%d/cancel", reservation.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("CancelReservation() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+	var cancelled models.Reservation
+	json.Unmarshal(w.Body.Bytes(), &cancelled)
+	if cancelled.CancellationFee != 5 {
+		t.Errorf("Cancellation fee = %v, want 5", cancelled.CancellationFee)
+	}
+
+	var player models.Player
+	database.DB.First(&player, playerID)
+	if player.Balance != -5 {
+		t.Errorf("Player balance = %v, want -5", player.Balance)
+	}
+	var fees int64
+	database.DB.Model(&models.WalletTransaction{}).
+		Where("player_id = ? AND type = ?", playerID, models.WalletReservationFee).
+		Count(&fees)
+	if fees != 1 {
+		t.Errorf("Fee transactions = %d, want 1", fees)
+	}
+}
+
+func TestCheckInReservation(t *testing.T) {
+	router := setupTestEnvironment(t)
+	cleanupDatabase()
+
+	roomID, playerID := setupTestReservationData(t)
+
+	start := time.Now().UTC().Add(10 * time.Minute).Truncate(time.Minute)
+	end := start.Add(20 * time.Minute)
+	if end.Day() != start.Day() {
+		t.Skip("Reservation would cross midnight")
+	}
+	reservation := createTestReservation(t, router, roomID, playerID, start, end)
+
+	w := sendJSON(router, "POST", fmt.Sprintf("/reservations/%d/check-in", reservation.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("CheckInReservation() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+	var checkedIn models.Reservation
+	json.Unmarshal(w.Body.Bytes(), &checkedIn)
+	if checkedIn.Status != models.ReservationCheckedIn || checkedIn.CheckedInAt == nil {
+		t.Errorf("Reservation after check-in = %+v, want %s", checkedIn, models.ReservationCheckedIn)
+	}
+
+	if w := sendJSON(router, "POST", fmt.Sprintf("/reservations/%d/cancel", reservation.ID), nil); w.Code != http.StatusConflict {
+		t.Errorf("CancelReservation() after check-in status = %v, want %v", w.Code, http.StatusConflict)
+	}
+}
+
+func TestMoveReservationInsideCutoff(t *testing.T) {
+	router := setupTestEnvironment(t)
+	cleanupDatabase()
+
+	roomID, playerID := setupTestReservationData(t)
+
+	// Moving a late booking away is refused, so cancelling it still costs the fee
+	start := time.Now().UTC().Add(2 * time.Hour).Truncate(time.Hour)
+	reservation := createTestReservation(t, router, roomID, playerID, start, start.Add(30*time.Minute))
+
+	w := sendJSON(router, "PATCH", fmt.Sprintf("/reservations/%d", reservation.ID), map[string]interface{}{
+		"date": start.AddDate(0, 0, 21).Format("2006-01-02"),
+	})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("UpdateReservation() inside the cutoff status = %v, want %v, response = %v", w.Code, http.StatusConflict, w.Body.String())
+	}
+
+	w = sendJSON(router, "POST", fmt.Sprintf("/reservations/%d/cancel", reservation.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("CancelReservation() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+	var cancelled models.Reservation
+	json.Unmarshal(w.Body.Bytes(), &cancelled)
+	if cancelled.CancellationFee != 5 {
+		t.Errorf("Cancellation fee after a refused move = %v, want 5", cancelled.CancellationFee)
+	}
+
+	// Nor can a booking outside the cutoff be moved into the past or the cutoff
+	day := time.Now().UTC().AddDate(0, 0, 3).Truncate(24 * time.Hour)
+	later := createTestReservation(t, router, roomID, playerID, day.Add(10*time.Hour), day.Add(11*time.Hour))
+	soon := start.Add(time.Hour)
+	moves := []struct {
+		name    string
+		payload map[string]interface{}
+	}{
+		{"Move Into The Past", map[string]interface{}{"date": time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")}},
+		{"Move Inside The Cutoff", map[string]interface{}{
+			"date":       soon.Format("2006-01-02"),
+			"start_time": soon.Format("15:04"),
+			"end_time":   soon.Add(30 * time.Minute).Format("15:04"),
+		}},
+	}
+	for _, tt := range moves {
+		t.Run(tt.name, func(t *testing.T) {
+			w := sendJSON(router, "PATCH", fmt.Sprintf("/reservations/%d", later.ID), tt.payload)
+			if w.Code != http.StatusConflict {
+				t.Errorf("UpdateReservation() status = %v, want %v, response = %v", w.Code, http.StatusConflict, w.Body.String())
+			}
+		})
+	}
+}
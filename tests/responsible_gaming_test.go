@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupTestResponsibleGaming(t *testing.T) uint {
+	player := models.Player{
+		Name:  fmt.Sprintf("Test Player %d", time.Now().UnixNano()),
+		Level: 1,
+	}
+	if err := database.DB.Create(&player).Error; err != nil {
+		t.Fatalf("Failed to create test player: %v", err)
+	}
+	return player.ID
+}
+
+func sendJSON(router *gin.Engine, method, path string, payload interface{}) *httptest.ResponseRecorder {
+	payloadBytes, _ := json.Marshal(payload)
+	req := httptest.NewRequest(method, path, bytes.NewReader(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPlayerLimits(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestResponsibleGaming(t)
+	limitsPath := fmt.Sprintf("/players/%d/limits", playerID)
+
+	t.Run("Set Limit", func(t *testing.T) {
+		w := sendJSON(router, "PUT", limitsPath, map[string]interface{}{
+			"type": "entry_count", "period": "day", "amount": 1,
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("SetPlayerLimit() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+		}
+	})
+
+	t.Run("Increase Is Delayed", func(t *testing.T) {
+		w := sendJSON(router, "PUT", limitsPath, map[string]interface{}{
+			"type": "entry_count", "period": "day", "amount": 10,
+		})
+		var limit models.PlayerLimit
+		json.Unmarshal(w.Body.Bytes(), &limit)
+		if limit.Amount != 1 || limit.PendingAmount == nil || *limit.PendingAmount != 10 {
+			t.Errorf("SetPlayerLimit() amount = %v, pending = %v, want 1 and pending 10", limit.Amount, limit.PendingAmount)
+		}
+	})
+
+	t.Run("Invalid Type", func(t *testing.T) {
+		w := sendJSON(router, "PUT", limitsPath, map[string]interface{}{
+			"type": "time_on_site", "period": "day", "amount": 1,
+		})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("SetPlayerLimit() status = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Entry Limit Enforced", func(t *testing.T) {
+		database.DB.Create(&models.Challenge{PlayerID: playerID, Amount: 20.01})
+
+		w := sendJSON(router, "POST", "/challenges", map[string]interface{}{
+			"player_id": playerID, "amount": 20.01,
+		})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("JoinChallenge() status = %v, want %v", w.Code, http.StatusForbidden)
+		}
+	})
+
+	t.Run("Audit Trail", func(t *testing.T) {
+		req := httptest.NewRequest("GET", limitsPath+"/audit", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var audits []models.ResponsibleGamingAudit
+		if err := json.Unmarshal(w.Body.Bytes(), &audits); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(audits) != 2 {
+			t.Errorf("GetResponsibleGamingAudit() returned %d entries, want 2", len(audits))
+		}
+	})
+}
+
+func TestSelfExclusion(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestResponsibleGaming(t)
+
+	w := sendJSON(router, "POST", fmt.Sprintf("/players/%d/self-exclusion", playerID), map[string]interface{}{
+		"days": 30,
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("StartSelfExclusion() short period status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+
+	w = sendJSON(router, "POST", fmt.Sprintf("/players/%d/self-exclusion", playerID), map[string]interface{}{
+		"permanent": true,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("StartSelfExclusion() status = %v, want %v", w.Code, http.StatusCreated)
+	}
+
+	w = sendJSON(router, "POST", "/payments", map[string]interface{}{
+		"player_id": playerID, "amount": 50, "method": "credit_card",
+	})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("ProcessPayment() status = %v, want %v", w.Code, http.StatusForbidden)
+	}
+
+	w = sendJSON(router, "POST", "/challenges", map[string]interface{}{
+		"player_id": playerID, "amount": 20.01,
+	})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("JoinChallenge() status = %v, want %v", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestConcurrentDepositsRespectLimit(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestResponsibleGaming(t)
+
+	w := sendJSON(router, "PUT", fmt.Sprintf("/players/%d/limits", playerID), map[string]interface{}{
+		"type": "deposit", "period": "day", "amount": 100,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("SetPlayerLimit() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	// Each deposit fits the limit on its own, but not together
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			codes <- sendJSON(router, "POST", "/payments", map[string]interface{}{
+				"player_id": playerID, "amount": 60, "method": "credit_card", "details": "4111111111111111",
+			}).Code
+		}()
+	}
+	successes := 0
+	for i := 0; i < 2; i++ {
+		if <-codes == http.StatusOK {
+			successes++
+		}
+	}
+	if successes > 1 {
+		t.Errorf("%d concurrent deposits succeeded, want at most 1 within the limit", successes)
+	}
+}
+
+func TestPendingLimitChangeAppliedOnce(t *testing.T) {
+	router := setupTestEnvironment(t)
+	playerID := setupTestResponsibleGaming(t)
+	limitsPath := fmt.Sprintf("/players/%d/limits", playerID)
+
+	sendJSON(router, "PUT", limitsPath, map[string]interface{}{"type": "deposit", "period": "day", "amount": 100})
+	sendJSON(router, "PUT", limitsPath, map[string]interface{}{"type": "deposit", "period": "day", "amount": 500})
+	// Make the delayed increase due
+	database.DB.Model(&models.PlayerLimit{}).Where("player_id = ?", playerID).
+		Update("pending_effective_at", time.Now().Add(-time.Minute))
+
+	done := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		go func() {
+			req := httptest.NewRequest("GET", limitsPath, nil)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		<-done
+	}
+
+	var applied int64
+	database.DB.Model(&models.ResponsibleGamingAudit{}).
+		Where("player_id = ? AND action = ?", playerID, "limit_increase_applied").
+		Count(&applied)
+	if applied != 1 {
+		t.Errorf("Increase applied %d times, want 1", applied)
+	}
+}
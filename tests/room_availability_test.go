@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRoomAvailability(t *testing.T) {
+	router := setupTestEnvironment(t)
+	cleanupDatabase()
+
+	w := sendJSON(router, "POST", "/rooms", map[string]interface{}{
+		"name":      fmt.Sprintf("Daytime Room %d", time.Now().UnixNano()),
+		"opens_at":  "09:00",
+		"closes_at": "18:00",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateRoom() status = %v, want %v, response = %v", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var room models.Room
+	json.Unmarshal(w.Body.Bytes(), &room)
+	other := createTestRoom(t, router, 8)
+	playerID := setupTestLog(t)
+
+	for _, hours := range [][2]string{{"18:00", "09:00"}, {"09:00", "25:00"}} {
+		w := sendJSON(router, "POST", "/rooms", map[string]interface{}{
+			"name":      fmt.Sprintf("Bad Hours Room %d", time.Now().UnixNano()),
+			"opens_at":  hours[0],
+			"closes_at": hours[1],
+		})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("CreateRoom() with hours %v status = %v, want %v", hours, w.Code, http.StatusBadRequest)
+		}
+	}
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Truncate(24 * time.Hour)
+	date := tomorrow.Format("2006-01-02")
+	reserve := func(start, end string) int {
+		return sendJSON(router, "POST", "/reservations", map[string]interface{}{
+			"room_id":    room.ID,
+			"player_id":  playerID,
+			"date":       date,
+			"start_time": start,
+			"end_time":   end,
+		}).Code
+	}
+	if code := reserve("10:00", "11:00"); code != http.StatusCreated {
+		t.Fatalf("CreateReservation() status = %v, want %v", code, http.StatusCreated)
+	}
+	// Bookings that only touch the existing one are fine
+	for _, times := range [][2]string{{"09:00", "10:00"}, {"11:00", "12:00"}} {
+		if code := reserve(times[0], times[1]); code != http.StatusCreated {
+			t.Errorf("CreateReservation() back to back %v status = %v, want %v", times, code, http.StatusCreated)
+		}
+	}
+	if code := reserve("10:30", "11:30"); code != http.StatusConflict {
+		t.Errorf("CreateReservation() overlapping status = %v, want %v", code, http.StatusConflict)
+	}
+	if code := reserve("08:00", "09:30"); code != http.StatusConflict {
+		t.Errorf("CreateReservation() before opening status = %v, want %v", code, http.StatusConflict)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("POST", fmt.Sprintf("/rooms/%d/maintenance", room.ID), map[string]interface{}{
+		"starts_at": tomorrow.Add(14 * time.Hour),
+		"ends_at":   tomorrow.Add(15 * time.Hour),
+	}, "test-admin-token"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateMaintenanceWindow() status = %v, want %v, response = %v", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/rooms/%d/availability?from=%s&slot=1h", room.ID, date), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetRoomAvailability() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+	var availability struct {
+		Slots []struct {
+			Start  time.Time `json:"start"`
+			Free   bool      `json:"free"`
+			Reason string    `json:"reason"`
+		} `json:"slots"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &availability)
+	if len(availability.Slots) != 24 {
+		t.Fatalf("GetRoomAvailability() returned %d slots, want 24", len(availability.Slots))
+	}
+	wantReasons := map[int]string{8: "closed", 9: "reserved", 10: "reserved", 11: "reserved", 12: "", 14: "maintenance", 18: "closed"}
+	for hour, reason := range wantReasons {
+		slot := availability.Slots[hour]
+		if slot.Reason != reason || slot.Free != (reason == "") {
+			t.Errorf("Slot at %s = free %v, reason %q, want reason %q", slot.Start, slot.Free, slot.Reason, reason)
+		}
+	}
+
+	invalid := []string{
+		fmt.Sprintf("/rooms/%d/availability?slot=1m", room.ID),
+		fmt.Sprintf("/rooms/%d/availability?from=%s&to=%s", room.ID, date, tomorrow.AddDate(0, 0, -1).Format("2006-01-02")),
+		fmt.Sprintf("/rooms/%d/availability?from=%s&to=%s", room.ID, date, tomorrow.AddDate(0, 2, 0).Format("2006-01-02")),
+		"/rooms/availability?from=" + date,
+	}
+	for _, path := range invalid {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s status = %v, want %v", path, w.Code, http.StatusBadRequest)
+		}
+	}
+
+	spans := []struct {
+		name     string
+		from, to time.Time
+		query    string
+		wantIDs  []uint
+	}{
+		{"Reserved Hour", tomorrow.Add(10 * time.Hour), tomorrow.Add(11 * time.Hour), "", []uint{other.ID}},
+		{"Free Hour", tomorrow.Add(12 * time.Hour), tomorrow.Add(13 * time.Hour), "", []uint{room.ID, other.ID}},
+		{"Min Capacity", tomorrow.Add(12 * time.Hour), tomorrow.Add(13 * time.Hour), "&min_capacity=5", []uint{other.ID}},
+		{"After Closing", tomorrow.Add(17 * time.Hour), tomorrow.Add(19 * time.Hour), "", []uint{other.ID}},
+	}
+	for _, tt := range spans {
+		t.Run(tt.name, func(t *testing.T) {
+			path := fmt.Sprintf("/rooms/availability?from=%s&to=%s%s",
+				url.QueryEscape(tt.from.Format(time.RFC3339)), url.QueryEscape(tt.to.Format(time.RFC3339)), tt.query)
+			req := httptest.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("FindAvailableRooms() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+			}
+			var response struct {
+				Rooms []models.Room `json:"rooms"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			var ids []uint
+			for _, r := range response.Rooms {
+				ids = append(ids, r.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("FindAvailableRooms() = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func createTestRoom(t *testing.T, router *gin.Engine, capacity int) models.Room {
+	w := sendJSON(router, "POST", "/rooms", map[string]interface{}{
+		"name":     fmt.Sprintf("Occupancy Room %d", time.Now().UnixNano()),
+		"capacity": capacity,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateRoom() status = %v, want %v, response = %v", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var room models.Room
+	if err := json.Unmarshal(w.Body.Bytes(), &room); err != nil {
+		t.Fatalf("Failed to parse room: %v", err)
+	}
+	return room
+}
+
+func TestRoomOccupancy(t *testing.T) {
+	router := setupTestEnvironment(t)
+	cleanupDatabase()
+
+	room := createTestRoom(t, router, 1)
+	other := createTestRoom(t, router, 2)
+	first := setupTestLog(t)
+	second := setupTestLog(t)
+
+	roomPath := fmt.Sprintf("/rooms/%d", room.ID)
+	otherPath := fmt.Sprintf("/rooms/%d", other.ID)
+
+	w := sendJSON(router, "POST", roomPath+"/join", map[string]interface{}{"player_id": first})
+	if w.Code != http.StatusOK {
+		t.Fatalf("JoinRoom() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+	var joined struct {
+		Room models.Room `json:"room"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &joined)
+	if joined.Room.Occupancy != 1 || joined.Room.Status != models.RoomStatusOccupied {
+		t.Errorf("Room after join = %+v, want occupancy 1 and status %q", joined.Room, models.RoomStatusOccupied)
+	}
+
+	conflicts := []struct {
+		name   string
+		path   string
+		player uint
+	}{
+		{"Room Full", roomPath + "/join", second},
+		{"Already In Another Room", otherPath + "/join", first},
+		{"Leave Room Not Joined", otherPath + "/leave", second},
+	}
+	for _, tt := range conflicts {
+		t.Run(tt.name, func(t *testing.T) {
+			w := sendJSON(router, "POST", tt.path, map[string]interface{}{"player_id": tt.player})
+			if w.Code != http.StatusConflict {
+				t.Errorf("status = %v, want %v, response = %v", w.Code, http.StatusConflict, w.Body.String())
+			}
+		})
+	}
+
+	w = sendJSON(router, "PUT", roomPath, map[string]interface{}{"name": room.Name, "capacity": 0})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("UpdateRoom() with capacity 0 status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+	w = sendJSON(router, "DELETE", roomPath, nil)
+	if w.Code != http.StatusConflict {
+		t.Errorf("DeleteRoom() with occupants status = %v, want %v", w.Code, http.StatusConflict)
+	}
+
+	req := httptest.NewRequest("GET", roomPath+"/occupants", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetRoomOccupants() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var occupants struct {
+		Occupancy int                   `json:"occupancy"`
+		Occupants []models.RoomOccupant `json:"occupants"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &occupants)
+	if occupants.Occupancy != 1 || len(occupants.Occupants) != 1 || occupants.Occupants[0].PlayerID != first {
+		t.Errorf("GetRoomOccupants() = %+v, want only player %d", occupants, first)
+	}
+
+	w = sendJSON(router, "POST", roomPath+"/leave", map[string]interface{}{"player_id": first})
+	if w.Code != http.StatusOK {
+		t.Fatalf("LeaveRoom() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+	var left struct {
+		Room models.Room `json:"room"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &left)
+	if left.Room.Occupancy != 0 || left.Room.Status != models.RoomStatusAvailable {
+		t.Errorf("Room after leave = %+v, want occupancy 0 and status %q", left.Room, models.RoomStatusAvailable)
+	}
+
+	w = sendJSON(router, "POST", roomPath+"/leave", map[string]interface{}{"player_id": first})
+	if w.Code != http.StatusConflict {
+		t.Errorf("LeaveRoom() twice status = %v, want %v", w.Code, http.StatusConflict)
+	}
+
+	// Entering and leaving are logged by the server
+	var count int64
+	database.DB.Model(&models.GameLog{}).
+		Where("player_id = ? AND action IN ?", first, []models.LogActionType{models.ActionEnterRoom, models.ActionLeaveRoom}).
+		Count(&count)
+	if count != 2 {
+		t.Errorf("Room logs = %d, want 2", count)
+	}
+
+	w = sendJSON(router, "POST", "/rooms/999999/join", map[string]interface{}{"player_id": first})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("JoinRoom() with unknown room status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUpdateRoomStatus(t *testing.T) {
+	router := setupTestEnvironment(t)
+	cleanupDatabase()
+
+	room := createTestRoom(t, router, 2)
+	playerID := setupTestLog(t)
+	statusPath := fmt.Sprintf("/rooms/%d/status", room.ID)
+	joinPath := fmt.Sprintf("/rooms/%d/join", room.ID)
+	leavePath := fmt.Sprintf("/rooms/%d/leave", room.ID)
+
+	steps := []struct {
+		name       string
+		payload    map[string]interface{}
+		token      string
+		wantStatus int
+	}{
+		{"Missing Admin Token", map[string]interface{}{"status": "maintenance"}, "", http.StatusUnauthorized},
+		{"Unknown Status", map[string]interface{}{"status": "closed"}, "test-admin-token", http.StatusBadRequest},
+		{"Set Occupied", map[string]interface{}{"status": "occupied"}, "test-admin-token", http.StatusBadRequest},
+		{"Enter Maintenance", map[string]interface{}{"status": "maintenance", "reason": "new carpet"}, "test-admin-token", http.StatusOK},
+		{"Leave Maintenance", map[string]interface{}{"status": "available"}, "test-admin-token", http.StatusOK},
+	}
+	for _, tt := range steps {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, sendAdminJSON("POST", statusPath, tt.payload, tt.token))
+			if w.Code != tt.wantStatus {
+				t.Errorf("UpdateRoomStatus() status = %v, want %v, response = %v", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	// An occupied room has to empty out before maintenance, and cannot be made
+	// available by hand to get around that
+	if w := sendJSON(router, "POST", joinPath, map[string]interface{}{"player_id": playerID}); w.Code != http.StatusOK {
+		t.Fatalf("JoinRoom() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	for _, status := range []string{"maintenance", "available", "maintenance"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, sendAdminJSON("POST", statusPath, map[string]interface{}{"status": status}, "test-admin-token"))
+		if w.Code != http.StatusConflict {
+			t.Errorf("UpdateRoomStatus(%s) on an occupied room status = %v, want %v", status, w.Code, http.StatusConflict)
+		}
+	}
+	if w := sendJSON(router, "POST", leavePath, map[string]interface{}{"player_id": playerID}); w.Code != http.StatusOK {
+		t.Fatalf("LeaveRoom() status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	req := httptest.NewRequest("GET", statusPath+"/history", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetRoomStatusHistory() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var history []models.RoomStatusChange
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil {
+		t.Fatalf("Failed to parse history: %v", err)
+	}
+
+	// Newest first
+	want := []struct {
+		to     models.RoomStatus
+		source models.RoomStatusSource
+	}{
+		{models.RoomStatusAvailable, models.RoomStatusSourceOccupancy},
+		{models.RoomStatusOccupied, models.RoomStatusSourceOccupancy},
+		{models.RoomStatusAvailable, models.RoomStatusSourceManual},
+		{models.RoomStatusMaintenance, models.RoomStatusSourceManual},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("GetRoomStatusHistory() returned %d changes, want %d: %+v", len(history), len(want), history)
+	}
+	for i, change := range history {
+		if change.ToStatus != want[i].to || change.Source != want[i].source {
+			t.Errorf("Change %d = %s by %s, want %s by %s", i, change.ToStatus, change.Source, want[i].to, want[i].source)
+		}
+	}
+	if history[3].Reason != "new carpet" {
+		t.Errorf("Reason = %q, want %q", history[3].Reason, "new carpet")
+	}
+
+	// A room in maintenance by hand cannot be booked until it is available again
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	reserve := func() int {
+		return sendJSON(router, "POST", "/reservations", map[string]interface{}{
+			"room_id":    room.ID,
+			"player_id":  playerID,
+			"date":       tomorrow,
+			"start_time": "10:00",
+			"end_time":   "11:00",
+		}).Code
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("POST", statusPath, map[string]interface{}{"status": "maintenance"}, "test-admin-token"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("UpdateRoomStatus() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if code := reserve(); code != http.StatusConflict {
+		t.Errorf("CreateReservation() during maintenance status = %v, want %v", code, http.StatusConflict)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("POST", statusPath, map[string]interface{}{"status": "available"}, "test-admin-token"))
+	if code := reserve(); code != http.StatusCreated {
+		t.Errorf("CreateReservation() after maintenance status = %v, want %v", code, http.StatusCreated)
+	}
+}
+
+func TestMaintenanceWindows(t *testing.T) {
+	router := setupTestEnvironment(t)
+	cleanupDatabase()
+
+	room := createTestRoom(t, router, 2)
+	playerID := setupTestLog(t)
+	maintenancePath := fmt.Sprintf("/rooms/%d/maintenance", room.ID)
+
+	// A window that has already started puts the room into maintenance at once
+	now := time.Now().UTC()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("POST", maintenancePath, map[string]interface{}{
+		"starts_at": now.Add(-time.Minute),
+		"ends_at":   now.Add(time.Hour),
+		"reason":    "lights",
+	}, "test-admin-token"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateMaintenanceWindow() status = %v, want %v, response = %v", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var current models.MaintenanceWindow
+	json.Unmarshal(w.Body.Bytes(), &current)
+	if current.StartedAt == nil {
+		t.Errorf("Window %+v was not started", current)
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/rooms/%d", room.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var got models.Room
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if got.Status != models.RoomStatusMaintenance {
+		t.Errorf("Room status = %q, want %q", got.Status, models.RoomStatusMaintenance)
+	}
+
+	if w := sendJSON(router, "POST", fmt.Sprintf("/rooms/%d/join", room.ID), map[string]interface{}{"player_id": playerID}); w.Code != http.StatusConflict {
+		t.Errorf("JoinRoom() during maintenance status = %v, want %v", w.Code, http.StatusConflict)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("POST", fmt.Sprintf("/rooms/%d/status", room.ID), map[string]interface{}{"status": "available"}, "test-admin-token"))
+	if w.Code != http.StatusConflict {
+		t.Errorf("UpdateRoomStatus() during a window status = %v, want %v", w.Code, http.StatusConflict)
+	}
+
+	// Reservations are blocked during scheduled windows
+	tomorrow := now.AddDate(0, 0, 1).Truncate(24 * time.Hour)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("POST", maintenancePath, map[string]interface{}{
+		"starts_at": tomorrow.Add(10 * time.Hour),
+		"ends_at":   tomorrow.Add(12 * time.Hour),
+	}, "test-admin-token"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateMaintenanceWindow() status = %v, want %v, response = %v", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	windows := []struct {
+		name       string
+		starts     time.Time
+		ends       time.Time
+		wantStatus int
+	}{
+		{"Overlapping Window", tomorrow.Add(11 * time.Hour), tomorrow.Add(13 * time.Hour), http.StatusConflict},
+		{"Ends Before Start", tomorrow.Add(15 * time.Hour), tomorrow.Add(14 * time.Hour), http.StatusBadRequest},
+		{"Ended Window", now.Add(-2 * time.Hour), now.Add(-time.Hour), http.StatusBadRequest},
+	}
+	for _, tt := range windows {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, sendAdminJSON("POST", maintenancePath, map[string]interface{}{
+				"starts_at": tt.starts,
+				"ends_at":   tt.ends,
+			}, "test-admin-token"))
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateMaintenanceWindow() status = %v, want %v, response = %v", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	reservations := []struct {
+		name       string
+		start, end string
+		wantStatus int
+	}{
+		{"During Maintenance", "11:00", "13:00", http.StatusConflict},
+		{"After Maintenance", "12:00", "13:00", http.StatusCreated},
+	}
+	for _, tt := range reservations {
+		t.Run(tt.name, func(t *testing.T) {
+			w := sendJSON(router, "POST", "/reservations", map[string]interface{}{
+				"room_id":    room.ID,
+				"player_id":  playerID,
+				"date":       tomorrow.Format("2006-01-02"),
+				"start_time": tt.start,
+				"end_time":   tt.end,
+			})
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateReservation() status = %v, want %v, response = %v", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	// Windows cannot be put over a booking
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("POST", maintenancePath, map[string]interface{}{
+		"starts_at": tomorrow.Add(12*time.Hour + 30*time.Minute),
+		"ends_at":   tomorrow.Add(14 * time.Hour),
+	}, "test-admin-token"))
+	if w.Code != http.StatusConflict {
+		t.Errorf("CreateMaintenanceWindow() over a reservation status = %v, want %v, response = %v", w.Code, http.StatusConflict, w.Body.String())
+	}
+
+	// Cancelling the current window makes the room available again
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("DELETE", fmt.Sprintf("%s/%d", maintenancePath, current.ID), nil, "test-admin-token"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("CancelMaintenanceWindow() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("DELETE", fmt.Sprintf("%s/%d", maintenancePath, current.ID), nil, "test-admin-token"))
+	if w.Code != http.StatusConflict {
+		t.Errorf("CancelMaintenanceWindow() twice status = %v, want %v", w.Code, http.StatusConflict)
+	}
+
+	req = httptest.NewRequest("GET", fmt.Sprintf("/rooms/%d", room.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if got.Status != models.RoomStatusAvailable {
+		t.Errorf("Room status after cancelling = %q, want %q", got.Status, models.RoomStatusAvailable)
+	}
+
+	req = httptest.NewRequest("GET", maintenancePath, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var upcoming []models.MaintenanceWindow
+	json.Unmarshal(w.Body.Bytes(), &upcoming)
+	if len(upcoming) != 1 {
+		t.Errorf("ListMaintenanceWindows() returned %d windows, want 1", len(upcoming))
+	}
+}
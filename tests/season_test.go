@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateSeason(t *testing.T) {
+	router := setupTestEnvironment(t)
+
+	tests := []struct {
+		name       string
+		payload    map[string]interface{}
+		token      string
+		wantStatus int
+	}{
+		{
+			name: "Valid Season",
+			payload: map[string]interface{}{
+				"name":       fmt.Sprintf("Season %d", time.Now().UnixNano()),
+				"start_time": time.Now().Format(time.RFC3339),
+				"end_time":   time.Now().AddDate(0, 1, 0).Format(time.RFC3339),
+				"prize_pool": 1000,
+				"top_n":      3,
+			},
+			token:      "test-admin-token",
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "End Before Start",
+			payload: map[string]interface{}{
+				"name":       fmt.Sprintf("Season %d", time.Now().UnixNano()),
+				"start_time": time.Now().Format(time.RFC3339),
+				"end_time":   time.Now().AddDate(0, -1, 0).Format(time.RFC3339),
+				"prize_pool": 1000,
+				"top_n":      3,
+			},
+			token:      "test-admin-token",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Not Admin",
+			payload: map[string]interface{}{
+				"name": "Season",
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, sendAdminJSON("POST", "/seasons", tt.payload, tt.token))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateSeason() status = %v, want %v, response = %v", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFinalizeSeason(t *testing.T) {
+	router := setupTestEnvironment(t)
+
+	start := time.Now().Add(-2 * time.Hour)
+	season := models.Season{
+		Name:        fmt.Sprintf("Season %d", time.Now().UnixNano()),
+		StartTime:   start,
+		EndTime:     time.Now().Add(-time.Minute),
+		PrizePool:   300,
+		TopN:        2,
+		EntryPoints: 1,
+		WinPoints:   100,
+		Status:      models.SeasonStatusActive,
+	}
+	database.DB.Create(&season)
+
+	// First: one win, second: three entries, third: one entry
+	var playerIDs []uint
+	for i, outcomes := range [][]bool{{true}, {false, false, false}, {false}} {
+		player := models.Player{Name: fmt.Sprintf("Season Player %d %d", i, time.Now().UnixNano()), Level: 1}
+		database.DB.Create(&player)
+		playerIDs = append(playerIDs, player.ID)
+		for _, isWinner := range outcomes {
+			challenge := models.Challenge{PlayerID: player.ID, Amount: 20.01, IsWinner: isWinner}
+			challenge.CreatedAt = start.Add(time.Minute)
+			database.DB.Create(&challenge)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("POST", fmt.Sprintf("/seasons/%d/finalize", season.ID), nil, "test-admin-token"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("FinalizeSeason() status = %v, want %v, response = %v", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var response struct {
+		Standings []models.SeasonStanding `json:"standings"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(response.Standings) != 3 {
+		t.Fatalf("FinalizeSeason() returned %d standings, want 3", len(response.Standings))
+	}
+
+	wantPrizes := []float64{200, 100, 0}
+	for i, standing := range response.Standings {
+		if standing.PlayerID != playerIDs[i] || standing.Prize != wantPrizes[i] {
+			t.Errorf("Standing %d = player %v prize %v, want player %v prize %v",
+				i+1, standing.PlayerID, standing.Prize, playerIDs[i], wantPrizes[i])
+		}
+	}
+
+	var winner models.Player
+	database.DB.First(&winner, playerIDs[0])
+	if winner.Balance != 200 {
+		t.Errorf("Winner balance = %v, want 200", winner.Balance)
+	}
+
+	// Finalizing again must not pay out twice
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, sendAdminJSON("POST", fmt.Sprintf("/seasons/%d/finalize", season.ID), nil, "test-admin-token"))
+	database.DB.First(&winner, playerIDs[0])
+	if w.Code != http.StatusOK || winner.Balance != 200 {
+		t.Errorf("Second FinalizeSeason() status = %v, balance = %v, want 200 and unchanged balance", w.Code, winner.Balance)
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/seasons/%d/leaderboard", season.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GetSeasonLeaderboard() status = %v, want %v", w.Code, http.StatusOK)
+	}
+}
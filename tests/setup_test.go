@@ -1,9 +1,12 @@
 package tests
 
 import (
+	"context"
 	"interview_Ping_20241219/internal/api"
+	"interview_Ping_20241219/internal/cache"
 	"interview_Ping_20241219/internal/config"
 	"interview_Ping_20241219/internal/database"
+	"interview_Ping_20241219/internal/services"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -18,6 +21,9 @@ func setupTestEnvironment(t *testing.T) *gin.Engine {
 
 	// Initialize test database
 	database.InitDB()
+	cache.InitRedis()
+	services.StartLogBuffer()
+	services.StartLogTail()
 
 	// Clean up database
 	cleanupDatabase()
@@ -33,12 +39,30 @@ func cleanupDatabase() {
 
 	// Delete in correct order to respect foreign key constraints
 	// First, delete all dependent tables
+	db.Exec("DELETE FROM responsible_gaming_audits")
+	db.Exec("DELETE FROM player_restrictions")
+	db.Exec("DELETE FROM player_limits")
+	db.Exec("DELETE FROM wallet_transactions")
 	db.Exec("DELETE FROM payments")   // Delete payments first
 	db.Exec("DELETE FROM game_logs")  // Then logs
+	db.Exec("DELETE FROM game_log_tombstones")
+	db.Exec("DELETE FROM log_checkpoints")
+	db.Exec("DELETE FROM log_chain_heads")
+	db.Exec("DELETE FROM season_standings")
+	db.Exec("DELETE FROM seasons")
+	db.Exec("DELETE FROM house_revenues")
 	db.Exec("DELETE FROM challenges") // Then challenges
 	db.Exec("DELETE FROM challenge_pools")
+	db.Exec("DELETE FROM room_occupants")
+	db.Exec("DELETE FROM room_status_changes")
+	db.Exec("DELETE FROM maintenance_windows")
 	db.Exec("DELETE FROM reservations") // Then reservations
 	db.Exec("DELETE FROM rooms")        // Then rooms
 	db.Exec("DELETE FROM players")      // Then players
 	db.Exec("DELETE FROM levels")       // Finally levels
+
+	// Drop cached data derived from the tables above
+	if cache.Redis != nil {
+		cache.Redis.FlushDB(context.Background())
+	}
 }